@@ -22,6 +22,7 @@ func main() {
 		Usage:     "Initialize a devnet configuration file",
 		Args:      true,
 		ArgsUsage: "[<file-name>]",
+		Flags:     []cli.Flag{&flags.FastFlag},
 		Action:    cmds.InitCmd,
 	})
 
@@ -32,6 +33,14 @@ func main() {
 		ArgsUsage: "[<file-name>]",
 		Flags: []cli.Flag{
 			&flags.DevnetNameFlag,
+			&flags.KeepOnFailureFlag,
+			&flags.VerboseFlag,
+			&flags.RebuildFlag,
+			&flags.AllowDirtyFlag,
+			&flags.JobsFlag,
+			&flags.StrictResponsesFlag,
+			&flags.FromSnapshotFlag,
+			&flags.BackendFlag,
 			&flags.KurtosisPackageFlag,
 		},
 		Action: cmds.StartCmd,
@@ -40,7 +49,7 @@ func main() {
 	app.Commands = append(app.Commands, &cli.Command{
 		Name:   "stop",
 		Usage:  "Stop devnet from configuration file",
-		Flags:  []cli.Flag{&flags.DevnetNameFlag},
+		Flags:  []cli.Flag{&flags.DevnetNameFlag, &flags.BackendFlag},
 		Action: cmds.StopCmd,
 	})
 
@@ -49,17 +58,85 @@ func main() {
 		Usage:     "Get a devnet contract or EOA address",
 		Args:      true,
 		ArgsUsage: "<contract-name>...",
-		Flags:     []cli.Flag{&flags.DevnetNameFlag},
+		Flags:     []cli.Flag{&flags.DevnetNameFlag, &flags.BackendFlag},
 		Action:    cmds.GetAddress,
 	})
 
 	app.Commands = append(app.Commands, &cli.Command{
 		Name:   "get-ports",
 		Usage:  "Get the published ports on the devnet",
-		Flags:  []cli.Flag{&flags.DevnetNameFlag},
+		Flags:  []cli.Flag{&flags.DevnetNameFlag, &flags.BackendFlag},
 		Action: cmds.GetPorts,
 	})
 
+	app.Commands = append(app.Commands, &cli.Command{
+		Name:  "export",
+		Usage: "Export a devnet to other formats",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "compose",
+				Usage: "Export the devnet's services and chain as a docker-compose project",
+				Description: "Running devnets are exported with their resolved templates, inputs and chain state. " +
+					"Otherwise, the devnet is exported from its config only, without inputs and with templates kept as-is.",
+				Args:      true,
+				ArgsUsage: "[<file-name>]",
+				Flags:     []cli.Flag{&flags.DevnetNameFlag, &flags.BackendFlag, &flags.OutputFlag},
+				Action:    cmds.ExportComposeCmd,
+			},
+		},
+	})
+
+	app.Commands = append(app.Commands, &cli.Command{
+		Name:  "snapshot",
+		Usage: "Save the state of a running devnet, to start new devnets from it",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "save",
+				Usage:     "Save the devnet's chain state, artifacts, keys and addresses to a file",
+				Args:      true,
+				ArgsUsage: "<snapshot-file>",
+				Flags:     []cli.Flag{&flags.DevnetNameFlag, &flags.BackendFlag},
+				Action:    cmds.SnapshotSaveCmd,
+			},
+		},
+	})
+
+	app.Commands = append(app.Commands, &cli.Command{
+		Name:      "doctor",
+		Usage:     "Check the environment for common problems",
+		Args:      true,
+		ArgsUsage: "[<file-name>]",
+		Flags:     []cli.Flag{&flags.JsonFlag},
+		Action:    cmds.DoctorCmd,
+	})
+
+	app.Commands = append(app.Commands, &cli.Command{
+		Name:  "engine",
+		Usage: "Manage the Kurtosis engine devnets run in",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "start",
+				Usage:  "Start the Kurtosis engine, with the version expected by avs-devnet",
+				Action: cmds.EngineStartCmd,
+			},
+			{
+				Name:   "stop",
+				Usage:  "Stop the Kurtosis engine. Running devnets are kept",
+				Action: cmds.EngineStopCmd,
+			},
+			{
+				Name:   "restart",
+				Usage:  "Restart the Kurtosis engine, with the version expected by avs-devnet",
+				Action: cmds.EngineRestartCmd,
+			},
+			{
+				Name:   "status",
+				Usage:  "Show whether the Kurtosis engine is running, and if its version is compatible",
+				Action: cmds.EngineStatusCmd,
+			},
+		},
+	})
+
 	if err := app.Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
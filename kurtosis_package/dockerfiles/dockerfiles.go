@@ -0,0 +1,10 @@
+// Package dockerfiles embeds the Kurtosis package's Dockerfiles, for images the CLI builds itself.
+package dockerfiles
+
+import _ "embed"
+
+// Dockerfile of the contract deployers.
+// Its `private_deployer` stage is used for repos that need credentials to be cloned.
+//
+//go:embed contract_deployer.Dockerfile
+var ContractDeployer string
@@ -0,0 +1,380 @@
+// Package devnet starts AVS devnets from Go code, and exposes their endpoints, contracts, and keys,
+// along with go-ethereum clients and transactors ready to be used with abigen bindings.
+// It's meant to be used from integration tests, in place of mocks.
+// See the devnettest package for helpers tied to a test's lifetime.
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Name used for devnets when none is specified.
+const DefaultName = "devnet"
+
+// Options accepted by Start.
+type Options struct {
+	// Name of the devnet. Defaults to DefaultName.
+	Name string
+	// Devnet configuration, as loaded by config.LoadFromPath or config.Unmarshal.
+	// Defaults to config.DefaultConfig().
+	Config config.DevnetConfig
+	// Path to the working directory for the devnet, used when resolving relative paths.
+	// Defaults to the current directory.
+	WorkingDir string
+	// URL of the kurtosis package to run. Defaults to the one matching this version.
+	KurtosisPackageUrl string
+	// Keep the devnet around if the start fails or is interrupted
+	KeepOnFailure bool
+	// Print the full output of each step
+	Verbose bool
+	// Rebuild images and local repo scripts even if their inputs didn't change
+	Rebuild bool
+	// Allow deploying local repo refs that are checked out with uncommitted changes
+	AllowDirty bool
+	// Maximum number of preparation tasks to run in parallel
+	Jobs int
+	// Backend to start the devnet in. Defaults to Kurtosis.
+	Backend backend.Backend
+}
+
+// A handle to a running devnet.
+type Devnet struct {
+	name         string
+	devnetConfig config.DevnetConfig
+	backend      backend.Backend
+	enclave      backend.Enclave
+	// Name of the execution client's service used for RPC
+	elService string
+	keys      map[string]Key
+	chainID   *big.Int
+
+	// Guards the fields below, which change when services are restarted
+	mu sync.Mutex
+	// Public endpoints by service name and port name
+	ports  map[string]cmds.ServicePorts
+	client *ethclient.Client
+	// Contents of the JSON artifacts read so far, by artifact name
+	artifacts map[string]string
+}
+
+// A key made available in the devnet, either generated or taken from the config.
+type Key struct {
+	Name string
+	// One of config.KeyTypeEcdsa or config.KeyTypeBls
+	Type string
+	// Address of the key. Only set for ECDSA keys.
+	Address string
+	// Private key, in hex for ECDSA keys and in decimal for BLS keys
+	PrivateKey string
+	// Password of the generated keystore. Empty for keys taken from the config.
+	Password string
+}
+
+// Starts a new devnet, and returns a handle to it.
+// ECDSA keys without funds, like the ones given only by private key, are funded by the deployer.
+// The devnet keeps running until Stop is called.
+func Start(ctx context.Context, opts Options) (*Devnet, error) {
+	startOpts, err := opts.toStartOptions()
+	if err != nil {
+		return nil, err
+	}
+	err = cmds.Start(ctx, startOpts)
+	if err != nil {
+		return nil, err
+	}
+	devnet, err := AttachWithBackend(ctx, startOpts.Backend, startOpts.DevnetName, startOpts.DevnetConfig)
+	if err == nil {
+		err = devnet.FundKeys(ctx)
+		if err != nil {
+			devnet.Client().Close()
+			devnet = nil
+		}
+	}
+	if err != nil && !opts.KeepOnFailure {
+		stopErr := cmds.StopWithBackend(context.WithoutCancel(ctx), startOpts.Backend, startOpts.DevnetName)
+		return nil, errors.Join(err, stopErr)
+	}
+	return devnet, err
+}
+
+// Returns a handle to an already running devnet.
+// The config should be the one the devnet was started with, and is used to find its keys.
+// No transactions are sent. Use Devnet.FundKeys to fund keys added to the config after the start.
+func Attach(ctx context.Context, name string, devnetConfig config.DevnetConfig) (*Devnet, error) {
+	b, err := backend.NewKurtosis()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize kurtosis context: %w", err)
+	}
+	return AttachWithBackend(ctx, b, name, devnetConfig)
+}
+
+// Same as Attach, but for a devnet running in the given backend.
+func AttachWithBackend(
+	ctx context.Context,
+	b backend.Backend,
+	name string,
+	devnetConfig config.DevnetConfig,
+) (*Devnet, error) {
+	if !b.EnclaveExists(ctx, name) {
+		return nil, fmt.Errorf("devnet '%s' %w", name, cmds.ErrEnclaveNotExists)
+	}
+	enclave, err := b.GetEnclave(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enclave: %w", err)
+	}
+	ports, err := cmds.GetServicePorts(ctx, enclave)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service ports: %w", err)
+	}
+	keys, err := loadKeys(ctx, enclave, devnetConfig)
+	if err != nil {
+		return nil, err
+	}
+	devnet := &Devnet{
+		name:         name,
+		devnetConfig: devnetConfig,
+		backend:      b,
+		enclave:      enclave,
+		ports:        ports,
+		elService:    findElService(ports),
+		keys:         keys,
+		artifacts:    make(map[string]string),
+	}
+	err = devnet.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return devnet, nil
+}
+
+// Returns the name of the devnet.
+func (d *Devnet) Name() string {
+	return d.name
+}
+
+// Returns the URL of the execution client's HTTP RPC, reachable from the host.
+func (d *Devnet) RPCURL() string {
+	endpoint, err := d.ServiceEndpoint(d.elService, "rpc")
+	if err != nil {
+		return ""
+	}
+	return "http://" + endpoint
+}
+
+// Returns the URL of the execution client's WebSocket RPC, reachable from the host.
+// Clients serving WebSockets on the HTTP RPC's port, like Anvil, have no separate port.
+func (d *Devnet) WSURL() string {
+	endpoint, err := d.ServiceEndpoint(d.elService, "ws")
+	if errors.Is(err, ErrNotFound) {
+		endpoint, err = d.ServiceEndpoint(d.elService, "rpc")
+	}
+	if err != nil {
+		return ""
+	}
+	return "ws://" + endpoint
+}
+
+// Returns the public "<ip>:<port>" endpoint of the named port of a service.
+func (d *Devnet) ServiceEndpoint(service string, port string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	servicePorts, ok := d.ports[service]
+	if !ok {
+		return "", fmt.Errorf("service '%s' %w", service, ErrNotFound)
+	}
+	endpoint, ok := servicePorts[port]
+	if !ok {
+		return "", fmt.Errorf("port '%s' of service '%s' %w", port, service, ErrNotFound)
+	}
+	return endpoint, nil
+}
+
+// Returns the address of a deployed contract.
+// The name has the same format as in `avs-devnet get-address`, like "eigenlayer_addresses:delegation".
+func (d *Devnet) Address(name string) (string, error) {
+	artifactName, contractName, ok := strings.Cut(name, ":")
+	if !ok || contractName == "" || strings.Contains(contractName, ":") {
+		return "", fmt.Errorf("invalid contract name '%s', expected '<artifact>:<contract>'", name)
+	}
+	file, err := d.readJsonArtifact(artifactName)
+	if err != nil {
+		return "", err
+	}
+	address, ok := cmds.LookupAddress(file, contractName)
+	address = strings.TrimSpace(address)
+	if !ok {
+		return "", fmt.Errorf("contract '%s' %w", name, ErrNotFound)
+	}
+	if !strings.HasPrefix(address, "0x") {
+		return "", fmt.Errorf("'%s' is not an address", name)
+	}
+	return address, nil
+}
+
+// Returns the key with the given name, from the `keys` section of the config.
+func (d *Devnet) Key(name string) (Key, error) {
+	key, ok := d.keys[name]
+	if !ok {
+		return Key{}, fmt.Errorf("key '%s' %w", name, ErrNotFound)
+	}
+	return key, nil
+}
+
+// Stops the devnet, removing all of its services and data.
+// Stopping an already stopped devnet is a no-op.
+func (d *Devnet) Stop() error {
+	d.Client().Close()
+	err := cmds.StopWithBackend(context.Background(), d.backend, d.name)
+	if errors.Is(err, cmds.ErrEnclaveNotExists) {
+		return nil
+	}
+	return err
+}
+
+// Returns the JSON file inside the artifact, caching it for later calls.
+func (d *Devnet) readJsonArtifact(artifactName string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if file, ok := d.artifacts[artifactName]; ok {
+		return file, nil
+	}
+	file, err := cmds.ReadJsonArtifact(context.Background(), d.enclave, artifactName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read artifact '%s': %w", artifactName, err)
+	}
+	d.artifacts[artifactName] = file
+	return file, nil
+}
+
+// Fills in the defaults, returning the options to pass to cmds.Start.
+func (opts Options) toStartOptions() (cmds.StartOptions, error) {
+	name := opts.Name
+	if name == "" {
+		name = DefaultName
+	}
+	devnetConfig := opts.Config
+	if len(devnetConfig.Marshal()) == 0 {
+		devnetConfig = config.DefaultConfig()
+	}
+	workingDir := opts.WorkingDir
+	if workingDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return cmds.StartOptions{}, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		workingDir = cwd
+	}
+	b := opts.Backend
+	if b == nil {
+		kurtosisBackend, err := backend.NewKurtosis()
+		if err != nil {
+			return cmds.StartOptions{}, fmt.Errorf("failed to initialize kurtosis context: %w", err)
+		}
+		b = kurtosisBackend
+	}
+	return cmds.StartOptions{
+		KurtosisPackageUrl: opts.KurtosisPackageUrl,
+		DevnetName:         name,
+		WorkingDir:         workingDir,
+		DevnetConfig:       devnetConfig,
+		KeepOnFailure:      opts.KeepOnFailure,
+		Verbose:            opts.Verbose,
+		Rebuild:            opts.Rebuild,
+		AllowDirty:         opts.AllowDirty,
+		Jobs:               opts.Jobs,
+		Backend:            b,
+	}, nil
+}
+
+// Returns the name of the first execution client's service, or an empty string if there's none.
+// The ethereum-package names them like "el-1-reth-lighthouse".
+func findElService(ports map[string]cmds.ServicePorts) string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		if strings.HasPrefix(name, "el-1-") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// Returns the keys declared in the config, by name.
+// Generated keys are read from the artifacts they were stored in.
+func loadKeys(ctx context.Context, enclave backend.Enclave, devnetConfig config.DevnetConfig) (map[string]Key, error) {
+	keys := make(map[string]Key, len(devnetConfig.Keys))
+	for i, configKey := range devnetConfig.Keys {
+		key := Key{
+			Name:       configKey.GetName(i),
+			Type:       configKey.GetType(),
+			Address:    configKey.Address,
+			PrivateKey: configKey.PrivateKey,
+		}
+		var err error
+		if configKey.Address == "" && configKey.PrivateKey == "" {
+			err = readGeneratedKey(ctx, enclave, &key)
+		} else if key.Address == "" && key.Type == config.KeyTypeEcdsa {
+			// The address isn't needed in the config when the private key is given
+			key.Address, err = ecdsaAddress(key.PrivateKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key '%s': %w", key.Name, err)
+		}
+		keys[key.Name] = key
+	}
+	return keys, nil
+}
+
+// Fills the key's data with the contents of the artifact it was generated into.
+func readGeneratedKey(ctx context.Context, enclave backend.Enclave, key *Key) error {
+	artifactFiles, err := enclave.InspectArtifact(ctx, key.Name)
+	if err != nil {
+		return err
+	}
+	files := make(map[string]string)
+	for _, file := range artifactFiles {
+		files[file.Path] = strings.TrimSpace(file.TextPreview)
+	}
+	key.PrivateKey = findFile(files, "private_key_hex.txt")
+	key.Password = findFile(files, "password.txt")
+	if key.PrivateKey == "" {
+		return fmt.Errorf("private key %w in artifact", ErrNotFound)
+	}
+	if key.Type == config.KeyTypeEcdsa {
+		keystore := findFile(files, ".ecdsa.key.json")
+		address, ok := cmds.LookupAddress(keystore, "address")
+		if !ok {
+			return fmt.Errorf("address %w in keystore", ErrNotFound)
+		}
+		key.Address = common.HexToAddress(strings.TrimSpace(address)).Hex()
+	}
+	return nil
+}
+
+// Returns the contents of the file whose path ends with the given suffix, or an empty string.
+func findFile(files map[string]string, suffix string) string {
+	for path, contents := range files {
+		if strings.HasSuffix(path, suffix) {
+			return contents
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package devnet_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet/devnettest"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultDevnet(t *testing.T) {
+	t.Parallel()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	rootDir := filepath.Join(cwd, "../..")
+
+	d := devnettest.Start(t, devnet.Options{
+		KurtosisPackageUrl: filepath.Join(rootDir, "kurtosis_package"),
+	})
+
+	require.Regexp(t, `^http://.+:\d+$`, d.RPCURL())
+	require.Regexp(t, `^ws://.+:\d+$`, d.WSURL())
+
+	address, err := d.Address("eigenlayer_addresses:delegation")
+	require.NoError(t, err)
+	require.Regexp(t, "^0x[0-9a-fA-F]{40}$", address)
+
+	key, err := d.Key("operator1_ecdsa")
+	require.NoError(t, err)
+	require.NotEmpty(t, key.PrivateKey)
+	require.Regexp(t, "^0x[0-9a-fA-F]{40}$", key.Address)
+
+	require.NotNil(t, d.Client())
+	chainID, err := d.Client().ChainID(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, chainID, d.ChainID())
+
+	opts, err := d.TransactOpts("operator1_ecdsa")
+	require.NoError(t, err)
+	require.Equal(t, key.Address, opts.From.Hex())
+	balance, err := d.Client().BalanceAt(context.Background(), opts.From, nil)
+	require.NoError(t, err)
+	require.Positive(t, balance.Sign())
+
+	_, err = d.TransactOpts("operator1_bls")
+	require.Error(t, err)
+
+	_, err = d.Key("missing")
+	require.ErrorIs(t, err, devnet.ErrNotFound)
+}
+
+func TestAttachDoesNotFundKeys(t *testing.T) {
+	t.Parallel()
+	chain := backendtest.NewStubChain(t, true)
+	fake := backendtest.NewFake()
+	fake.AddEnclave("devnet").SetServices(chain.Service())
+	// A key given only by its private key, which isn't funded by the Kurtosis package
+	devnetConfig, err := config.Unmarshal([]byte(`keys:
+  - name: user
+    type: ecdsa
+    private_key: "0x2a871d0798f97d79848a013d4936a73bf4cc922c825d33c1cf7073dff6d409c6"
+`))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	d, err := devnet.AttachWithBackend(ctx, fake, "devnet", devnetConfig)
+	require.NoError(t, err)
+	t.Cleanup(d.Client().Close)
+	require.Zero(t, chain.SentTxs())
+
+	// Funding is explicit
+	err = d.FundKeys(ctx)
+	require.ErrorContains(t, err, "failed to fund key 'user'")
+	require.Equal(t, 1, chain.SentTxs())
+}
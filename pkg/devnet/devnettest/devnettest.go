@@ -0,0 +1,49 @@
+// Package devnettest provides helpers for using devnets in Go tests.
+package devnettest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+)
+
+// Starts a devnet for the test, and stops it once the test and its subtests finish.
+// Unless opts.Name is set, the devnet is named after the test.
+// Any devnet left over with the same name, like from an interrupted run, is stopped first.
+// Fails the test if the devnet can't be started.
+func Start(t testing.TB, opts devnet.Options) *devnet.Devnet {
+	t.Helper()
+	if opts.Name == "" {
+		name, err := cmds.ToValidEnclaveName(t.Name())
+		if err != nil {
+			t.Fatalf("failed to generate devnet name: %v", err)
+		}
+		opts.Name = name
+	}
+	ctx := context.Background()
+
+	// Ensure the devnet isn't running
+	stopLeftover(ctx, opts)
+
+	d, err := devnet.Start(ctx, opts)
+	if err != nil {
+		t.Fatalf("failed to start devnet: %v", err)
+	}
+	t.Cleanup(func() {
+		if stopErr := d.Stop(); stopErr != nil {
+			t.Errorf("failed to stop devnet: %v", stopErr)
+		}
+	})
+	return d
+}
+
+// Stops a devnet left over with the same name, like from an interrupted run.
+func stopLeftover(ctx context.Context, opts devnet.Options) {
+	if opts.Backend != nil {
+		_ = cmds.StopWithBackend(ctx, opts.Backend, opts.Name)
+		return
+	}
+	_ = cmds.Stop(ctx, opts.Name)
+}
@@ -0,0 +1,161 @@
+package devnettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+)
+
+// Maximum length of the names generated for fixture devnets.
+const maxFixtureNameLength = 60
+
+// A devnet shared by all the tests of a package, started once from TestMain.
+// Sharing it avoids paying the devnet's start time on every test.
+//
+// Tests get the devnet via Fixture.Devnet, with these isolation guarantees:
+//   - Tests using the devnet run one at a time, even if they're parallel.
+//   - Before each test, the services in the config are restarted, which resets their in-memory state.
+//   - If the execution client supports snapshots (like anvil), the chain is also reverted to a
+//     snapshot taken after setup, so each test starts from the same on-chain state.
+//   - Otherwise, the chain's state is NOT reverted, and tests see the transactions sent by
+//     previous ones. Tests shouldn't depend on on-chain state modified by other tests in this case.
+//   - Contract addresses, keys, and the execution client's RPC are the same for every test.
+//     Other services' endpoints may change between tests.
+//
+// Tests calling t.Parallel must do so before calling Fixture.Devnet, and subtests
+// should use their parent's devnet instead of calling Fixture.Devnet again.
+type Fixture struct {
+	opts  devnet.Options
+	setup func(ctx context.Context, d *devnet.Devnet) error
+	// Starts the devnet. It's devnet.Start, except in this package's tests
+	startDevnet func(ctx context.Context, opts devnet.Options) (*devnet.Devnet, error)
+
+	// Held by the test using the devnet
+	mu     sync.Mutex
+	devnet *devnet.Devnet
+	// Empty if snapshots aren't supported
+	snapshotID string
+	// Whether a test used the devnet since the last reset
+	dirty bool
+}
+
+// Returns a fixture that starts a devnet with the given options, and runs `setup` on it.
+// `setup` is optional, and can deploy contracts or send transactions shared by all tests.
+// Unless opts.Name is set, the devnet is named after the package's dir.
+func NewFixture(opts devnet.Options, setup func(ctx context.Context, d *devnet.Devnet) error) *Fixture {
+	return &Fixture{opts: opts, setup: setup, startDevnet: devnet.Start}
+}
+
+// Starts the devnet, runs the setup, and then the tests, stopping the devnet afterwards.
+// Returns the exit code of the tests. It's meant to be called from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(fixture.Run(m))
+//	}
+func (f *Fixture) Run(m *testing.M) int {
+	ctx := context.Background()
+	err := f.start(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to start devnet fixture:", err)
+		return 1
+	}
+	defer func() {
+		if stopErr := f.devnet.Stop(); stopErr != nil {
+			fmt.Fprintln(os.Stderr, "Failed to stop devnet fixture:", stopErr)
+		}
+	}()
+	return m.Run()
+}
+
+// Returns the shared devnet, reset after any previous test as described in Fixture.
+// The test has exclusive access to the devnet until it finishes.
+func (f *Fixture) Devnet(t testing.TB) *devnet.Devnet {
+	t.Helper()
+	f.mu.Lock()
+	t.Cleanup(f.mu.Unlock)
+	if f.devnet == nil {
+		t.Fatal("devnet fixture isn't running. Call Fixture.Run from TestMain")
+	}
+	err := f.reset(context.Background())
+	if err != nil {
+		t.Fatalf("failed to reset devnet fixture: %v", err)
+	}
+	f.dirty = true
+	return f.devnet
+}
+
+// Starts the devnet, runs the setup, and takes the snapshot tests are reverted to.
+func (f *Fixture) start(ctx context.Context) error {
+	opts := f.opts
+	if opts.Name == "" {
+		name, err := fixtureName()
+		if err != nil {
+			return err
+		}
+		opts.Name = name
+	}
+	// Ensure the devnet isn't running
+	stopLeftover(ctx, opts)
+
+	d, err := f.startDevnet(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if f.setup != nil {
+		err = f.setup(ctx, d)
+		if err != nil {
+			return errors.Join(fmt.Errorf("setup failed: %w", err), d.Stop())
+		}
+	}
+	snapshotID, err := d.Snapshot(ctx)
+	if err != nil && !errors.Is(err, devnet.ErrSnapshotsUnsupported) {
+		return errors.Join(fmt.Errorf("failed to take snapshot: %w", err), d.Stop())
+	}
+	f.devnet = d
+	f.snapshotID = snapshotID
+	return nil
+}
+
+// Resets the devnet to its state after setup, if a previous test used it.
+func (f *Fixture) reset(ctx context.Context) error {
+	if !f.dirty {
+		return nil
+	}
+	if f.snapshotID != "" {
+		err := f.devnet.Revert(ctx, f.snapshotID)
+		if err != nil {
+			return err
+		}
+		// Reverting discards the snapshot, so we take it again
+		f.snapshotID, err = f.devnet.Snapshot(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	err := f.devnet.RestartServices(ctx)
+	if err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// Returns a devnet name for the package being tested, based on its dir.
+func fixtureName() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	name := "test-" + filepath.Base(cwd)
+	if len(name) > maxFixtureNameLength {
+		name = name[:maxFixtureNameLength]
+	}
+	return cmds.ToValidEnclaveName(name)
+}
@@ -0,0 +1,127 @@
+package devnettest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet/devnettest"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the fake backend and a fixture whose devnet is backed by a stub chain.
+func newStubFixture(t *testing.T, supportsSnapshots bool) (*backendtest.Fake, *devnettest.Fixture) {
+	t.Helper()
+	chain := backendtest.NewStubChain(t, supportsSnapshots)
+	devnetConfig, err := config.Unmarshal([]byte("services:\n  - name: operator\n    image: operator\n"))
+	require.NoError(t, err)
+	fake := backendtest.NewFake()
+	start := func(ctx context.Context, opts devnet.Options) (*devnet.Devnet, error) {
+		fake.AddEnclave(opts.Name).SetServices(chain.Service())
+		return devnet.AttachWithBackend(ctx, opts.Backend, opts.Name, opts.Config)
+	}
+	opts := devnet.Options{Name: "stub", Config: devnetConfig, Backend: fake}
+	fixture := devnettest.NewFixtureWithStart(opts, nil, start)
+	require.NoError(t, fixture.Start(context.Background()))
+	t.Cleanup(func() { require.NoError(t, fixture.Stop()) })
+	return fake, fixture
+}
+
+// Mines a block with the given RPC method, returning the new block number.
+func mineBlock(t *testing.T, d *devnet.Devnet, method string) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, d.Client().Client().CallContext(ctx, nil, method))
+	blockNumber, err := d.Client().BlockNumber(ctx)
+	require.NoError(t, err)
+	return blockNumber
+}
+
+// Returns the scripts run in the devnet's enclave.
+func scriptsRun(fake *backendtest.Fake) []string {
+	scripts := make([]string, 0)
+	for _, call := range fake.Calls() {
+		if call.Method == "RunScript" {
+			scripts = append(scripts, call.Args[1])
+		}
+	}
+	return scripts
+}
+
+func TestFixtureResetsStateBetweenTests(t *testing.T) {
+	t.Parallel()
+	fake, fixture := newStubFixture(t, true)
+
+	t.Run("mutates state", func(t *testing.T) {
+		d := fixture.Devnet(t)
+		require.Equal(t, uint64(1), mineBlock(t, d, "evm_mine"))
+		require.Equal(t, uint64(2), mineBlock(t, d, "evm_mine"))
+	})
+	// The first test doesn't restart services, as nothing used the devnet before
+	require.Empty(t, scriptsRun(fake))
+
+	t.Run("sees the state after setup", func(t *testing.T) {
+		d := fixture.Devnet(t)
+		blockNumber, err := d.Client().BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, blockNumber)
+		// The snapshot is taken again after reverting, so the next test is reset too
+		require.Equal(t, uint64(1), mineBlock(t, d, "evm_mine"))
+	})
+	require.Len(t, scriptsRun(fake), 1)
+
+	t.Run("is reset again", func(t *testing.T) {
+		d := fixture.Devnet(t)
+		blockNumber, err := d.Client().BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, blockNumber)
+	})
+	scripts := scriptsRun(fake)
+	require.Len(t, scripts, 2)
+	require.Contains(t, scripts[1], `plan.stop_service(name="operator")`)
+	require.Contains(t, scripts[1], `plan.start_service(name="operator")`)
+}
+
+func TestFixtureWithoutSnapshotsRestartsServices(t *testing.T) {
+	t.Parallel()
+	fake, fixture := newStubFixture(t, false)
+
+	t.Run("mutates state", func(t *testing.T) {
+		d := fixture.Devnet(t)
+		_, err := d.Snapshot(context.Background())
+		require.ErrorIs(t, err, devnet.ErrSnapshotsUnsupported)
+		require.Equal(t, uint64(1), mineBlock(t, d, "anvil_mine"))
+	})
+
+	t.Run("sees the previous state", func(t *testing.T) {
+		d := fixture.Devnet(t)
+		// Only the services are reset
+		blockNumber, err := d.Client().BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), blockNumber)
+	})
+	scripts := scriptsRun(fake)
+	require.Len(t, scripts, 1)
+	require.Contains(t, scripts[0], `plan.stop_service(name="operator")`)
+}
+
+func TestSnapshotAndRevert(t *testing.T) {
+	t.Parallel()
+	_, fixture := newStubFixture(t, true)
+	d := fixture.Devnet(t)
+	ctx := context.Background()
+
+	snapshotID, err := d.Snapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), mineBlock(t, d, "evm_mine"))
+	require.NoError(t, d.Revert(ctx, snapshotID))
+	blockNumber, err := d.Client().BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Zero(t, blockNumber)
+
+	// Snapshots are discarded once reverted to
+	err = d.Revert(ctx, snapshotID)
+	require.ErrorIs(t, err, devnet.ErrNotFound)
+}
@@ -0,0 +1,28 @@
+package devnettest
+
+import (
+	"context"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+)
+
+// Returns a fixture that starts its devnet with `start`, instead of devnet.Start.
+func NewFixtureWithStart(
+	opts devnet.Options,
+	setup func(ctx context.Context, d *devnet.Devnet) error,
+	start func(ctx context.Context, opts devnet.Options) (*devnet.Devnet, error),
+) *Fixture {
+	f := NewFixture(opts, setup)
+	f.startDevnet = start
+	return f
+}
+
+// Starts the fixture's devnet, like Fixture.Run does before running the tests.
+func (f *Fixture) Start(ctx context.Context) error {
+	return f.start(ctx)
+}
+
+// Stops the fixture's devnet, like Fixture.Run does after running the tests.
+func (f *Fixture) Stop() error {
+	return f.devnet.Stop()
+}
@@ -0,0 +1,46 @@
+// Tests of devnettest.Fixture against a real devnet, shared by all the tests in the package.
+// They're kept apart from the fixture's unit tests, which don't need a Kurtosis engine.
+package livetest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet"
+	"github.com/Layr-Labs/avs-devnet/pkg/devnet/devnettest"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:gochecknoglobals // the fixture is shared by all tests in the package
+var fixture = devnettest.NewFixture(devnet.Options{
+	KurtosisPackageUrl: func() string {
+		cwd, err := os.Getwd()
+		if err != nil {
+			panic(err)
+		}
+		return filepath.Join(cwd, "../../../../kurtosis_package")
+	}(),
+}, nil)
+
+func TestMain(m *testing.M) {
+	os.Exit(fixture.Run(m))
+}
+
+func TestFixtureDevnet(t *testing.T) {
+	t.Parallel()
+	d := fixture.Devnet(t)
+	_, err := d.Client().BlockNumber(context.Background())
+	require.NoError(t, err)
+	_, err = d.Key("operator1_ecdsa")
+	require.NoError(t, err)
+}
+
+func TestFixtureIsShared(t *testing.T) {
+	t.Parallel()
+	d := fixture.Devnet(t)
+	address, err := d.Address("eigenlayer_addresses:delegation")
+	require.NoError(t, err)
+	require.NotEmpty(t, address)
+}
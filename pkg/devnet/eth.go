@@ -0,0 +1,202 @@
+package devnet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// First pre-funded account of the ethereum-package, which the Kurtosis package deploys contracts with.
+const (
+	deployerKeyName    = "deployer"
+	deployerAddress    = "0x8943545177806ED17B9F23F0a21ee5948eCaa776"
+	deployerPrivateKey = "0xbcdf20249abf0ed6d944c0288fad489e33f66b3960d9e6229c1cd214ed3bbe31"
+)
+
+// Amount of ether sent to unfunded keys, same as the Kurtosis package sends to generated keys.
+const keyFundsEther = 10
+
+// Returns a client connected to the execution client's RPC.
+// It's closed when the devnet is stopped.
+func (d *Devnet) Client() *ethclient.Client {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.client
+}
+
+// Returns the chain ID of the devnet.
+func (d *Devnet) ChainID() *big.Int {
+	return new(big.Int).Set(d.chainID)
+}
+
+// Returns the pre-funded key used to deploy the contracts.
+func (d *Devnet) DeployerKey() Key {
+	return Key{
+		Name:       deployerKeyName,
+		Type:       config.KeyTypeEcdsa,
+		Address:    deployerAddress,
+		PrivateKey: deployerPrivateKey,
+	}
+}
+
+// Returns a new transactor that signs with the deployer key.
+func (d *Devnet) DeployerTransactOpts() (*bind.TransactOpts, error) {
+	return d.newTransactOpts(d.DeployerKey())
+}
+
+// Returns a new transactor that signs with the named ECDSA key, from the `keys` section of the config.
+// The key is funded when the devnet starts.
+func (d *Devnet) TransactOpts(keyName string) (*bind.TransactOpts, error) {
+	key, err := d.Key(keyName)
+	if err != nil {
+		return nil, err
+	}
+	return d.newTransactOpts(key)
+}
+
+// Returns a new transactor for the key.
+func (d *Devnet) newTransactOpts(key Key) (*bind.TransactOpts, error) {
+	if key.Type != config.KeyTypeEcdsa {
+		return nil, fmt.Errorf("key '%s' is not an ECDSA key", key.Name)
+	}
+	if key.PrivateKey == "" {
+		return nil, fmt.Errorf("key '%s' has no private key", key.Name)
+	}
+	privateKey, err := parseEcdsaKey(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("key '%s': %w", key.Name, err)
+	}
+	return bind.NewKeyedTransactorWithChainID(privateKey, d.chainID)
+}
+
+// Connects to the execution client's RPC, and fetches the chain ID.
+func (d *Devnet) connect(ctx context.Context) error {
+	client, err := d.dial(ctx)
+	if err != nil {
+		return err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	d.client = client
+	d.chainID = chainID
+	return nil
+}
+
+// Returns a new client connected to the execution client's RPC.
+func (d *Devnet) dial(ctx context.Context) (*ethclient.Client, error) {
+	rpcUrl := d.RPCURL()
+	if rpcUrl == "" {
+		return nil, fmt.Errorf("execution client's RPC %w", ErrNotFound)
+	}
+	client, err := ethclient.DialContext(ctx, rpcUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
+	}
+	return client, nil
+}
+
+// Sends funds from the deployer to the ECDSA keys without any balance, and waits for them to arrive.
+// Keys that were generated or have an address in the config are funded by the Kurtosis package already.
+// Start calls it, so it's only needed for devnets obtained with Attach.
+func (d *Devnet) FundKeys(ctx context.Context) error {
+	names := make([]string, 0, len(d.keys))
+	for name, key := range d.keys {
+		if key.Type == config.KeyTypeEcdsa && key.Address != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	client := d.Client()
+
+	deployer, err := parseEcdsaKey(deployerPrivateKey)
+	if err != nil {
+		return err
+	}
+	nonce, err := client.PendingNonceAt(ctx, common.HexToAddress(deployerAddress))
+	if err != nil {
+		return fmt.Errorf("failed to get deployer nonce: %w", err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+	signer := types.LatestSignerForChainID(d.chainID)
+	amount := new(big.Int).Mul(big.NewInt(keyFundsEther), big.NewInt(params.Ether))
+
+	txs := make([]*types.Transaction, 0)
+	for _, name := range names {
+		address := common.HexToAddress(d.keys[name].Address)
+		var balance *big.Int
+		balance, err = client.BalanceAt(ctx, address, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of key '%s': %w", name, err)
+		}
+		if balance.Sign() != 0 {
+			continue
+		}
+		var tx *types.Transaction
+		tx, err = types.SignNewTx(deployer, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      params.TxGas,
+			To:       &address,
+			Value:    amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sign funding tx for key '%s': %w", name, err)
+		}
+		err = client.SendTransaction(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to fund key '%s': %w", name, err)
+		}
+		nonce += 1
+		txs = append(txs, tx)
+	}
+	for _, tx := range txs {
+		var receipt *types.Receipt
+		receipt, err = bind.WaitMined(ctx, client, tx)
+		if err != nil {
+			return fmt.Errorf("failed waiting for funding tx: %w", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return errors.New("funding tx " + tx.Hash().Hex() + " failed")
+		}
+	}
+	return nil
+}
+
+// Returns the address of the ECDSA private key.
+func ecdsaAddress(privateKey string) (string, error) {
+	key, err := parseEcdsaKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
+// Parses a hex-encoded ECDSA private key, with or without the 0x prefix.
+func parseEcdsaKey(privateKey string) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECDSA private key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,98 @@
+package devnet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+)
+
+var ErrSnapshotsUnsupported = errors.New("the execution client doesn't support snapshots")
+
+// Takes a snapshot of the chain's state, returning its ID.
+// Fails with ErrSnapshotsUnsupported if the execution client doesn't support `evm_snapshot`,
+// like the default reth client. Dev clients like anvil or hardhat support it.
+func (d *Devnet) Snapshot(ctx context.Context) (string, error) {
+	var snapshotID string
+	err := d.Client().Client().CallContext(ctx, &snapshotID, "evm_snapshot")
+	if err != nil {
+		return "", wrapSnapshotError(err)
+	}
+	return snapshotID, nil
+}
+
+// Reverts the chain's state to the given snapshot.
+// Clients discard a snapshot once it's reverted to, so a new one must be taken to revert again.
+func (d *Devnet) Revert(ctx context.Context, snapshotID string) error {
+	var reverted bool
+	err := d.Client().Client().CallContext(ctx, &reverted, "evm_revert", snapshotID)
+	if err != nil {
+		return wrapSnapshotError(err)
+	}
+	if !reverted {
+		return fmt.Errorf("snapshot '%s' %w", snapshotID, ErrNotFound)
+	}
+	return nil
+}
+
+// Restarts the given services, or all the services in the config if none are given.
+// The services lose any in-memory state, but keep their files.
+// Endpoints may change, so they should be fetched again after restarting.
+func (d *Devnet) RestartServices(ctx context.Context, serviceNames ...string) error {
+	if len(serviceNames) == 0 {
+		for _, service := range d.devnetConfig.Services {
+			serviceNames = append(serviceNames, service.Name)
+		}
+	}
+	if len(serviceNames) == 0 {
+		return nil
+	}
+	var script strings.Builder
+	script.WriteString("def run(plan):\n")
+	for _, name := range serviceNames {
+		quotedName := strconv.Quote(name)
+		script.WriteString("    plan.stop_service(name=" + quotedName + ")\n")
+		script.WriteString("    plan.start_service(name=" + quotedName + ")\n")
+	}
+	err := d.enclave.RunScript(ctx, script.String())
+	if err != nil {
+		return fmt.Errorf("failed to restart services: %w", err)
+	}
+	return d.refreshEndpoints(ctx)
+}
+
+// Fetches the services' endpoints again, reconnecting the client if the RPC's changed.
+func (d *Devnet) refreshEndpoints(ctx context.Context) error {
+	oldRpcUrl := d.RPCURL()
+	ports, err := cmds.GetServicePorts(ctx, d.enclave)
+	if err != nil {
+		return fmt.Errorf("failed to get service ports: %w", err)
+	}
+	d.mu.Lock()
+	d.ports = ports
+	d.mu.Unlock()
+	if d.RPCURL() == oldRpcUrl {
+		return nil
+	}
+	client, err := d.dial(ctx)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.client.Close()
+	d.client = client
+	return nil
+}
+
+// Wraps errors from unknown RPC methods with ErrSnapshotsUnsupported.
+func wrapSnapshotError(err error) error {
+	if errdefs.IsMethodNotFound(err) {
+		return fmt.Errorf("%w: %w", ErrSnapshotsUnsupported, err)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+// Interfaces for the runtime devnets run in.
+// Commands only talk to the runtime through them, so they can be tested with a fake backend.
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+)
+
+// Names of the available backends.
+const (
+	KindKurtosis = "kurtosis"
+	KindDocker   = "docker"
+)
+
+var ErrUnknownBackend = errors.New("unknown backend")
+
+// Returns the backend with the given name (one of KindKurtosis or KindDocker).
+func New(kind string) (Backend, error) {
+	switch kind {
+	case KindKurtosis:
+		return NewKurtosis()
+	case KindDocker:
+		return NewDocker()
+	default:
+		return nil, fmt.Errorf("%w '%s', expected '%s' or '%s'", ErrUnknownBackend, kind, KindKurtosis, KindDocker)
+	}
+}
+
+// A runtime hosting devnets, each one inside its own enclave.
+type Backend interface {
+	// Checks if an enclave with the given name exists.
+	EnclaveExists(ctx context.Context, name string) bool
+	// Creates a new, empty enclave.
+	CreateEnclave(ctx context.Context, name string) (Enclave, error)
+	// Returns an existing enclave.
+	GetEnclave(ctx context.Context, name string) (Enclave, error)
+	// Destroys an enclave, along with its services and artifacts.
+	DestroyEnclave(ctx context.Context, name string) error
+}
+
+// An isolated environment holding a devnet's services and files artifacts.
+type Enclave interface {
+	// Returns the name of the enclave.
+	Name() string
+	// Uploads a local file or dir as a files artifact with the given name.
+	UploadFiles(ctx context.Context, path string, artifactName string) error
+	// Runs the Kurtosis package at the given local path or github.com URL, with the serialized args.
+	// Returns the stream of response lines, and a function that stops the run.
+	RunPackage(
+		ctx context.Context,
+		packageUrl string,
+		serializedParams string,
+	) (chan progress_reporters.KurtosisResponse, context.CancelFunc, error)
+	// Runs a Starlark script, waiting until it finishes.
+	RunScript(ctx context.Context, script string) error
+	// Returns the files inside a files artifact.
+	InspectArtifact(ctx context.Context, artifactName string) ([]ArtifactFile, error)
+	// Writes the full contents of a files artifact into the given dir, creating it if needed.
+	DownloadArtifact(ctx context.Context, artifactName string, dstDir string) error
+	// Returns the names of all the files artifacts.
+	ListArtifacts(ctx context.Context) ([]string, error)
+	// Returns all the services.
+	ListServices(ctx context.Context) ([]Service, error)
+	// Returns the last `numLines` log lines of each service, keyed by service name.
+	ServiceLogs(ctx context.Context, numLines uint32) (map[string][]string, error)
+}
+
+// A file inside a files artifact.
+type ArtifactFile struct {
+	// Path of the file, relative to the artifact's root
+	Path string
+	// Contents of the file. May be truncated for big files.
+	TextPreview string
+}
+
+// A service running inside an enclave.
+type Service struct {
+	Name string
+	// IP address inside the enclave, reachable from other services
+	IPAddress string
+	// Endpoints reachable from the host, like "127.0.0.1:8545", by port name
+	Ports map[string]string
+}
@@ -0,0 +1,15 @@
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUnknownBackend(t *testing.T) {
+	t.Parallel()
+	b, err := backend.New("podman")
+	require.ErrorIs(t, err, backend.ErrUnknownBackend)
+	require.Nil(t, b)
+}
@@ -0,0 +1,403 @@
+// In-memory backend for unit tests, which records the calls made to it.
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+	kapi "github.com/kurtosis-tech/kurtosis/api/golang/core/kurtosis_core_rpc_api_bindings"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/binding_constructors"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Description of the first progress line sent by Kurtosis.
+const interpretationDescription = "Interpreting plan - execution will begin shortly"
+
+// A call made to the fake backend or one of its enclaves.
+type Call struct {
+	Method string
+	// The call's string arguments, starting with the enclave's name for enclave methods
+	Args []string
+}
+
+// A backend that keeps its enclaves in memory, and records all calls made to it.
+// It's safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	calls    []Call
+	enclaves map[string]*FakeEnclave
+	// Errors to return, by method name
+	errs map[string]error
+	// Response streams replayed by RunPackage, in order
+	responses [][]progress_reporters.KurtosisResponse
+}
+
+// An enclave of the fake backend. Its contents can be set directly by tests.
+type FakeEnclave struct {
+	fake *Fake
+	name string
+
+	// Guarded by the fake's mutex
+	services  []backend.Service
+	artifacts map[string][]backend.ArtifactFile
+	logs      map[string][]string
+	// Local paths uploaded, by artifact name
+	uploads map[string]string
+}
+
+// Returns a fake backend with no enclaves.
+func NewFake() *Fake {
+	return &Fake{
+		enclaves: make(map[string]*FakeEnclave),
+		errs:     make(map[string]error),
+	}
+}
+
+// Makes all future calls to the given method fail with `err`.
+// Passing a nil error makes the method succeed again.
+func (f *Fake) FailOn(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = err
+}
+
+// Queues a response stream, which is replayed by the next call to RunPackage.
+// Like Kurtosis, streams should start with a progress line (see Progress).
+// When no streams are queued, runs finish successfully right after interpretation.
+func (f *Fake) QueueResponses(responses ...progress_reporters.KurtosisResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, responses)
+}
+
+// Returns the calls made so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Returns the names of the methods called so far, in order.
+func (f *Fake) Methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	methods := make([]string, 0, len(f.calls))
+	for _, call := range f.calls {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+// Adds an empty enclave, as if it was created before.
+func (f *Fake) AddEnclave(name string) *FakeEnclave {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addEnclave(name)
+}
+
+// Returns the enclave with the given name, or nil if it doesn't exist.
+func (f *Fake) Enclave(name string) *FakeEnclave {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.enclaves[name]
+}
+
+func (f *Fake) EnclaveExists(_ context.Context, name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_ = f.record("EnclaveExists", name)
+	_, ok := f.enclaves[name]
+	return ok
+}
+
+func (f *Fake) CreateEnclave(_ context.Context, name string) (backend.Enclave, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CreateEnclave", name); err != nil {
+		return nil, err
+	}
+	if _, ok := f.enclaves[name]; ok {
+		return nil, fmt.Errorf("enclave '%s' already exists", name)
+	}
+	return f.addEnclave(name), nil
+}
+
+func (f *Fake) GetEnclave(_ context.Context, name string) (backend.Enclave, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("GetEnclave", name); err != nil {
+		return nil, err
+	}
+	enclave, ok := f.enclaves[name]
+	if !ok {
+		return nil, fmt.Errorf("enclave '%s' %w", name, ErrNotFound)
+	}
+	return enclave, nil
+}
+
+func (f *Fake) DestroyEnclave(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("DestroyEnclave", name); err != nil {
+		return err
+	}
+	if _, ok := f.enclaves[name]; !ok {
+		return fmt.Errorf("enclave '%s' %w", name, ErrNotFound)
+	}
+	delete(f.enclaves, name)
+	return nil
+}
+
+// Records a call, returning the error set for the method, if any.
+// Must be called with the mutex held.
+func (f *Fake) record(method string, args ...string) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	return f.errs[method]
+}
+
+// Must be called with the mutex held.
+func (f *Fake) addEnclave(name string) *FakeEnclave {
+	enclave := &FakeEnclave{
+		fake:      f,
+		name:      name,
+		artifacts: make(map[string][]backend.ArtifactFile),
+		logs:      make(map[string][]string),
+		uploads:   make(map[string]string),
+	}
+	f.enclaves[name] = enclave
+	return enclave
+}
+
+// Sets the services returned by ListServices.
+func (e *FakeEnclave) SetServices(services ...backend.Service) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	e.services = services
+}
+
+// Sets the files of an artifact.
+func (e *FakeEnclave) SetArtifact(artifactName string, files ...backend.ArtifactFile) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	e.artifacts[artifactName] = files
+}
+
+// Sets the log lines of a service.
+func (e *FakeEnclave) SetLogs(serviceName string, lines ...string) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	e.logs[serviceName] = lines
+}
+
+// Returns the local paths uploaded to the enclave, by artifact name.
+func (e *FakeEnclave) Uploads() map[string]string {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	uploads := make(map[string]string, len(e.uploads))
+	for name, path := range e.uploads {
+		uploads[name] = path
+	}
+	return uploads
+}
+
+func (e *FakeEnclave) Name() string {
+	return e.name
+}
+
+// Records the upload, and stores the uploaded files as an artifact.
+// The files are read right away, since they are usually temporary.
+func (e *FakeEnclave) UploadFiles(_ context.Context, path string, artifactName string) error {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("UploadFiles", e.name, path, artifactName); err != nil {
+		return err
+	}
+	if _, ok := e.artifacts[artifactName]; ok {
+		return fmt.Errorf("artifact '%s' already exists", artifactName)
+	}
+	files, err := readArtifactFiles(path)
+	if err != nil {
+		return err
+	}
+	e.uploads[artifactName] = path
+	e.artifacts[artifactName] = files
+	return nil
+}
+
+// Replays the next queued response stream.
+func (e *FakeEnclave) RunPackage(
+	ctx context.Context,
+	packageUrl string,
+	serializedParams string,
+) (chan progress_reporters.KurtosisResponse, context.CancelFunc, error) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("RunPackage", e.name, packageUrl, serializedParams); err != nil {
+		return nil, nil, err
+	}
+	responses := []progress_reporters.KurtosisResponse{
+		Progress(interpretationDescription, 0, 0),
+		RunFinished(true),
+	}
+	if len(e.fake.responses) > 0 {
+		responses = e.fake.responses[0]
+		e.fake.responses = e.fake.responses[1:]
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	responseChan := make(chan progress_reporters.KurtosisResponse)
+	go func() {
+		defer close(responseChan)
+		for _, response := range responses {
+			select {
+			case responseChan <- response:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+	return responseChan, cancel, nil
+}
+
+func (e *FakeEnclave) RunScript(_ context.Context, script string) error {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	return e.fake.record("RunScript", e.name, script)
+}
+
+func (e *FakeEnclave) InspectArtifact(_ context.Context, artifactName string) ([]backend.ArtifactFile, error) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("InspectArtifact", e.name, artifactName); err != nil {
+		return nil, err
+	}
+	files, ok := e.artifacts[artifactName]
+	if !ok {
+		return nil, fmt.Errorf("artifact '%s' %w", artifactName, ErrNotFound)
+	}
+	return slices.Clone(files), nil
+}
+
+// Writes the artifact's files, using their previews as contents.
+func (e *FakeEnclave) DownloadArtifact(_ context.Context, artifactName string, dstDir string) error {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("DownloadArtifact", e.name, artifactName, dstDir); err != nil {
+		return err
+	}
+	files, ok := e.artifacts[artifactName]
+	if !ok {
+		return fmt.Errorf("artifact '%s' %w", artifactName, ErrNotFound)
+	}
+	if err := os.MkdirAll(dstDir, 0700); err != nil {
+		return err
+	}
+	for _, file := range files {
+		filePath := filepath.Join(dstDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filePath, []byte(file.TextPreview), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *FakeEnclave) ListArtifacts(_ context.Context) ([]string, error) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("ListArtifacts", e.name); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(e.artifacts))
+	for name := range e.artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (e *FakeEnclave) ListServices(_ context.Context) ([]backend.Service, error) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("ListServices", e.name); err != nil {
+		return nil, err
+	}
+	return slices.Clone(e.services), nil
+}
+
+func (e *FakeEnclave) ServiceLogs(_ context.Context, numLines uint32) (map[string][]string, error) {
+	e.fake.mu.Lock()
+	defer e.fake.mu.Unlock()
+	if err := e.fake.record("ServiceLogs", e.name); err != nil {
+		return nil, err
+	}
+	logs := make(map[string][]string, len(e.logs))
+	for name, lines := range e.logs {
+		start := max(0, len(lines)-int(numLines))
+		logs[name] = slices.Clone(lines[start:])
+	}
+	return logs, nil
+}
+
+// Reads the file or the files inside the dir at `path`, with paths relative to it.
+func readArtifactFiles(path string) ([]backend.ArtifactFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		contents, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, readErr
+		}
+		return []backend.ArtifactFile{{Path: filepath.Base(path), TextPreview: string(contents)}}, nil
+	}
+	files := make([]backend.ArtifactFile, 0)
+	err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil || entry.IsDir() {
+			return walkErr
+		}
+		contents, readErr := os.ReadFile(filePath)
+		if readErr != nil {
+			return readErr
+		}
+		relPath, relErr := filepath.Rel(path, filePath)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, backend.ArtifactFile{Path: filepath.ToSlash(relPath), TextPreview: string(contents)})
+		return nil
+	})
+	return files, err
+}
+
+// Returns a response line for a finished run.
+func RunFinished(success bool) progress_reporters.KurtosisResponse {
+	if !success {
+		return binding_constructors.NewStarlarkRunResponseLineFromRunFailureEvent()
+	}
+	return binding_constructors.NewStarlarkRunResponseLineFromRunSuccessEvent("")
+}
+
+// Returns a response line for an error during the run's execution.
+func ExecutionError(message string) progress_reporters.KurtosisResponse {
+	return binding_constructors.NewStarlarkRunResponseLineFromExecutionError(
+		&kapi.StarlarkExecutionError{ErrorMessage: message},
+	)
+}
+
+// Returns a response line for a progress update, with the given step description.
+func Progress(description string, currentStep uint32, totalSteps uint32) progress_reporters.KurtosisResponse {
+	return binding_constructors.NewStarlarkRunResponseLineFromSinglelineProgressInfo(description, currentStep, totalSteps)
+}
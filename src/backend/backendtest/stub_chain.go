@@ -0,0 +1,171 @@
+package backendtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Chain ID of stub chains, the same as the ethereum-package's default.
+const StubChainID = 3151908
+
+// IP address of the stub chain's service inside the enclave.
+const StubChainIP = "172.16.0.2"
+
+// An in-memory execution client served over JSON-RPC, for tests that connect to a devnet's chain.
+// Its only state is the block number. Blocks are mined with `evm_mine` or `anvil_mine`, like with Anvil.
+// Transactions are recorded and rejected.
+type StubChain struct {
+	// URL of the JSON-RPC server
+	URL string
+
+	mu          sync.Mutex
+	blockNumber uint64
+	// Block numbers by snapshot ID
+	snapshots map[string]uint64
+	nextID    int
+	sentTxs   int
+}
+
+// Starts a stub chain, which is stopped when the test finishes.
+// Without snapshot support, `evm_*` calls fail with "method not found", like with reth.
+func NewStubChain(t testing.TB, supportsSnapshots bool) *StubChain {
+	t.Helper()
+	chain := &StubChain{snapshots: make(map[string]uint64)}
+	server := rpc.NewServer()
+	apis := map[string]any{"eth": stubEthApi{chain}, "anvil": stubAnvilApi{chain}}
+	if supportsSnapshots {
+		apis["evm"] = stubEvmApi{chain}
+	}
+	for namespace, api := range apis {
+		if err := server.RegisterName(namespace, api); err != nil {
+			t.Fatalf("failed to register the stub chain's %s API: %v", namespace, err)
+		}
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+	chain.URL = httpServer.URL
+	return chain
+}
+
+// Returns the chain's service, named like the Anvil node started by the Kurtosis package.
+func (c *StubChain) Service() backend.Service {
+	return backend.Service{
+		Name:      "el-1-anvil",
+		IPAddress: StubChainIP,
+		Ports:     map[string]string{"rpc": strings.TrimPrefix(c.URL, "http://")},
+	}
+}
+
+// Returns the number of transactions sent to the chain.
+func (c *StubChain) SentTxs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sentTxs
+}
+
+// Returns the state dumped by `anvil_dumpState`, before compression.
+func (c *StubChain) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf(`{"block":{"number":"%s"},"accounts":{}}`, hexutil.EncodeUint64(c.blockNumber))
+}
+
+func (c *StubChain) mine() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blockNumber++
+}
+
+// The `eth` namespace, with the methods used when attaching to a devnet and funding keys.
+type stubEthApi struct{ chain *StubChain }
+
+func (api stubEthApi) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(StubChainID))
+}
+
+func (api stubEthApi) BlockNumber() hexutil.Uint64 {
+	api.chain.mu.Lock()
+	defer api.chain.mu.Unlock()
+	return hexutil.Uint64(api.chain.blockNumber)
+}
+
+func (api stubEthApi) GetTransactionCount(common.Address, string) hexutil.Uint64 {
+	return 0
+}
+
+func (api stubEthApi) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func (api stubEthApi) GetBalance(common.Address, string) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+func (api stubEthApi) SendRawTransaction(hexutil.Bytes) (common.Hash, error) {
+	api.chain.mu.Lock()
+	defer api.chain.mu.Unlock()
+	api.chain.sentTxs++
+	return common.Hash{}, errors.New("rejected by the stub chain")
+}
+
+// The `anvil` namespace.
+type stubAnvilApi struct{ chain *StubChain }
+
+func (api stubAnvilApi) Mine() {
+	api.chain.mine()
+}
+
+// Returns the gzipped state, like Anvil does.
+func (api stubAnvilApi) DumpState() (hexutil.Bytes, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(api.chain.State())); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// The `evm` namespace, with the snapshot methods of dev clients like Anvil.
+type stubEvmApi struct{ chain *StubChain }
+
+func (api stubEvmApi) Mine() {
+	api.chain.mine()
+}
+
+func (api stubEvmApi) Snapshot() string {
+	api.chain.mu.Lock()
+	defer api.chain.mu.Unlock()
+	api.chain.nextID++
+	id := hexutil.EncodeUint64(uint64(api.chain.nextID))
+	api.chain.snapshots[id] = api.chain.blockNumber
+	return id
+}
+
+func (api stubEvmApi) Revert(id string) bool {
+	api.chain.mu.Lock()
+	defer api.chain.mu.Unlock()
+	blockNumber, ok := api.chain.snapshots[id]
+	if !ok {
+		return false
+	}
+	// Like Anvil, the snapshot is discarded
+	delete(api.chain.snapshots, id)
+	api.chain.blockNumber = blockNumber
+	return true
+}
@@ -0,0 +1,359 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+)
+
+var ErrEnclaveNotFound = errors.New("enclave not found")
+
+// Labels used to find the Docker resources of each enclave.
+const (
+	enclaveLabel     = "com.layr-labs.avs-devnet.enclave"
+	serviceLabel     = "com.layr-labs.avs-devnet.service"
+	portLabelPrefix  = "com.layr-labs.avs-devnet.port."
+	networkPrefix    = "avs-devnet-"
+	containerNameSep = "--"
+)
+
+// Backend running devnets as plain Docker containers, without Kurtosis.
+// Each enclave is a dedicated Docker network, and files artifacts are stored in the user's cache dir.
+// Kurtosis packages are run by a Starlark interpreter implementing the subset of the Kurtosis API
+// used by the devnet's package, with a single Anvil node as the chain.
+type Docker struct {
+	// Dir holding the files artifacts of each enclave
+	stateDir string
+	docker   dockerExecutor
+}
+
+// An enclave of the Docker backend.
+type dockerEnclave struct {
+	name         string
+	artifactsDir string
+	docker       dockerExecutor
+	// Guards the creation of artifacts
+	mu sync.Mutex
+}
+
+// Returns a backend running devnets with the local Docker daemon.
+func NewDocker() (*Docker, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	docker := dockerCli{}
+	if _, err := docker.Output(context.Background(), "info", "--format", "{{.ServerVersion}}"); err != nil {
+		return nil, &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Docker{stateDir: filepath.Join(cacheDir, "avs-devnet", "docker"), docker: docker}, nil
+}
+
+func (d *Docker) EnclaveExists(ctx context.Context, name string) bool {
+	_, err := d.docker.Output(ctx, "network", "inspect", networkPrefix+name)
+	return err == nil
+}
+
+func (d *Docker) CreateEnclave(ctx context.Context, name string) (Enclave, error) {
+	_, err := d.docker.Output(ctx, "network", "create", "--label", enclaveLabel+"="+name, networkPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network: %w", err)
+	}
+	enclave := d.newEnclave(name)
+	// Remove artifacts left by a previous enclave with the same name
+	if err = os.RemoveAll(enclave.artifactsDir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(enclave.artifactsDir, 0700); err != nil {
+		return nil, err
+	}
+	return enclave, nil
+}
+
+func (d *Docker) GetEnclave(ctx context.Context, name string) (Enclave, error) {
+	if !d.EnclaveExists(ctx, name) {
+		return nil, fmt.Errorf("'%s': %w", name, ErrEnclaveNotFound)
+	}
+	return d.newEnclave(name), nil
+}
+
+func (d *Docker) DestroyEnclave(ctx context.Context, name string) error {
+	containerIds, err := listContainers(ctx, d.docker, name, false)
+	if err != nil {
+		return err
+	}
+	if len(containerIds) != 0 {
+		_, err = d.docker.Output(ctx, append([]string{"rm", "--force", "--volumes"}, containerIds...)...)
+		if err != nil {
+			return fmt.Errorf("failed to remove containers: %w", err)
+		}
+	}
+	if _, err = d.docker.Output(ctx, "network", "rm", networkPrefix+name); err != nil {
+		return fmt.Errorf("failed to remove network: %w", err)
+	}
+	return os.RemoveAll(d.newEnclave(name).artifactsDir)
+}
+
+func (d *Docker) newEnclave(name string) *dockerEnclave {
+	return &dockerEnclave{name: name, artifactsDir: filepath.Join(d.stateDir, name, "artifacts"), docker: d.docker}
+}
+
+func (e *dockerEnclave) Name() string {
+	return e.name
+}
+
+// Copies the file or the contents of the dir at `path` into a new artifact.
+func (e *dockerEnclave) UploadFiles(_ context.Context, path string, artifactName string) error {
+	dst, err := e.newArtifactDir(artifactName)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fsutil.CopyFile(path, filepath.Join(dst, filepath.Base(path)), info.Mode())
+	}
+	return fsutil.CopyDir(path, dst, nil)
+}
+
+func (e *dockerEnclave) RunPackage(
+	ctx context.Context,
+	packageUrl string,
+	serializedParams string,
+) (chan progress_reporters.KurtosisResponse, context.CancelFunc, error) {
+	packageDir, cleanup, err := fetchPackage(ctx, packageUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	responseChan := make(chan progress_reporters.KurtosisResponse)
+	go func() {
+		defer cleanup()
+		defer close(responseChan)
+		runPlan(runCtx, e, packageDir, "main.star", nil, serializedParams, responseChan)
+	}()
+	return responseChan, cancel, nil
+}
+
+func (e *dockerEnclave) RunScript(ctx context.Context, script string) error {
+	responseChan := make(chan progress_reporters.KurtosisResponse)
+	go func() {
+		defer close(responseChan)
+		runPlan(ctx, e, "", "script.star", []byte(script), "", responseChan)
+	}()
+	var runErr error
+	for response := range responseChan {
+		if starlarkErr := response.GetError(); starlarkErr != nil && runErr == nil {
+			runErr = fmt.Errorf("failed to run script: %s", starlarkErr.String())
+		}
+	}
+	return runErr
+}
+
+func (e *dockerEnclave) InspectArtifact(_ context.Context, artifactName string) ([]ArtifactFile, error) {
+	root, err := e.artifactDir(artifactName)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]ArtifactFile, 0)
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil || entry.IsDir() {
+			return walkErr
+		}
+		contents, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		relPath, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, ArtifactFile{Path: filepath.ToSlash(relPath), TextPreview: string(contents)})
+		return nil
+	})
+	return files, err
+}
+
+func (e *dockerEnclave) DownloadArtifact(_ context.Context, artifactName string, dstDir string) error {
+	src, err := e.artifactDir(artifactName)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dstDir, 0700); err != nil {
+		return err
+	}
+	return fsutil.CopyDir(src, dstDir, nil)
+}
+
+func (e *dockerEnclave) ListArtifacts(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(e.artifactsDir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func (e *dockerEnclave) ListServices(ctx context.Context) ([]Service, error) {
+	containerIds, err := listContainers(ctx, e.docker, e.name, true)
+	if err != nil || len(containerIds) == 0 {
+		return nil, err
+	}
+	output, err := e.docker.Output(ctx, append([]string{"container", "inspect"}, containerIds...)...)
+	if err != nil {
+		return nil, err
+	}
+	var containers []containerInfo
+	if err = json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container info: %w", err)
+	}
+	services := make([]Service, 0, len(containers))
+	for _, container := range containers {
+		services = append(services, Service{
+			Name:      container.Config.Labels[serviceLabel],
+			IPAddress: container.NetworkSettings.Networks[networkPrefix+e.name].IPAddress,
+			Ports:     container.publishedPorts(),
+		})
+	}
+	return services, nil
+}
+
+func (e *dockerEnclave) ServiceLogs(ctx context.Context, numLines uint32) (map[string][]string, error) {
+	services, err := e.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs := make(map[string][]string, len(services))
+	for _, service := range services {
+		output, logsErr := e.docker.Output(ctx, "logs", "--tail", fmt.Sprint(numLines), e.containerName(service.Name))
+		if logsErr != nil {
+			return nil, logsErr
+		}
+		logs[service.Name] = strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	}
+	return logs, nil
+}
+
+// Returns the name of the container running the given service.
+func (e *dockerEnclave) containerName(serviceName string) string {
+	return e.name + containerNameSep + serviceName
+}
+
+// Returns the dir of an existing artifact.
+func (e *dockerEnclave) artifactDir(artifactName string) (string, error) {
+	dir := filepath.Join(e.artifactsDir, artifactName)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("artifact '%s' not found: %w", artifactName, err)
+	}
+	return dir, nil
+}
+
+// Creates the dir of a new artifact, failing if it already exists.
+func (e *dockerEnclave) newArtifactDir(artifactName string) (string, error) {
+	if artifactName == "" || strings.ContainsAny(artifactName, `/\`) || artifactName == "." || artifactName == ".." {
+		return "", fmt.Errorf("invalid artifact name '%s'", artifactName)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	dir := filepath.Join(e.artifactsDir, artifactName)
+	if err := os.Mkdir(dir, 0700); errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("artifact '%s' already exists", artifactName)
+	} else if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Fields of `docker container inspect` used by the backend.
+type containerInfo struct {
+	Config struct {
+		Labels map[string]string
+	}
+	NetworkSettings struct {
+		Ports    map[string][]struct{ HostIp, HostPort string }
+		Networks map[string]struct{ IPAddress string }
+	}
+}
+
+// Returns the host endpoints of the container's ports, by port name.
+func (c containerInfo) publishedPorts() map[string]string {
+	ports := make(map[string]string)
+	for label, containerPort := range c.Config.Labels {
+		portName, ok := strings.CutPrefix(label, portLabelPrefix)
+		if !ok {
+			continue
+		}
+		bindings := c.NetworkSettings.Ports[containerPort]
+		if len(bindings) == 0 {
+			continue
+		}
+		ports[portName] = bindings[0].HostIp + ":" + bindings[0].HostPort
+	}
+	return ports
+}
+
+// Returns the IDs of the enclave's containers, optionally only the ones running services.
+func listContainers(ctx context.Context, docker dockerExecutor, enclaveName string, onlyServices bool) ([]string, error) {
+	args := []string{"ps", "--all", "--quiet", "--filter", "label=" + enclaveLabel + "=" + enclaveName}
+	if onlyServices {
+		args = append(args, "--filter", "label="+serviceLabel)
+	}
+	output, err := docker.Output(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Runs docker commands. Replaced by a stub in tests.
+type dockerExecutor interface {
+	// Runs a docker command, returning its stdout.
+	// Errors include the command's stderr.
+	Output(ctx context.Context, args ...string) ([]byte, error)
+	// Runs a docker command that forwards the exit code of the process inside the container.
+	// Returns its combined output and exit code.
+	OutputWithExitCode(ctx context.Context, args ...string) (string, int, error)
+}
+
+// Runs commands with the docker CLI.
+type dockerCli struct{}
+
+func (dockerCli) Output(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("`docker %s` failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func (dockerCli) OutputWithExitCode(ctx context.Context, args ...string) (string, int, error) {
+	output, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode(), nil
+	}
+	return string(output), 0, err
+}
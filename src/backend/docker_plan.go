@@ -0,0 +1,1152 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/Layr-Labs/avs-devnet/src/internal/maputil"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/binding_constructors"
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed docker_ethereum.star
+var ethereumPackageSrc []byte
+
+// Locator of the ethereum-package's main file, replaced by docker_ethereum.star.
+// The stand-in runs the importing package's anvil.star, so it must be next to the importer.
+const ethereumPackageLocator = "github.com/ethpandaops/ethereum-package/main.star"
+
+// Image used by `plan.run_sh` when none is given, same as in Kurtosis.
+const defaultRunShImage = "badouralix/curl-jq"
+
+// Default time to wait for a service's ports to open, same as in Kurtosis.
+const defaultPortWait = 15 * time.Second
+
+// Same as in Kurtosis.
+const (
+	interpretationDescription = "Interpreting plan - execution will begin shortly"
+	executionDescription      = "Starting execution"
+)
+
+// Dialect of the Starlark files, matching the one supported by Kurtosis.
+//
+//nolint:gochecknoglobals // this is a constant
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+	Recursion:       true,
+}
+
+// Returns the local dir of the Kurtosis package, and a function to clean it up.
+// Remote packages (e.g. "github.com/org/repo/path@ref") are shallow-cloned into a temporary dir.
+func fetchPackage(ctx context.Context, packageUrl string) (string, func(), error) {
+	if !strings.HasPrefix(packageUrl, "github.com/") {
+		packageDir, err := filepath.Abs(packageUrl)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err = os.Stat(filepath.Join(packageDir, "main.star")); err != nil {
+			return "", nil, fmt.Errorf("invalid package '%s': %w", packageUrl, err)
+		}
+		return packageDir, func() {}, nil
+	}
+	locator, ref, _ := strings.Cut(packageUrl, "@")
+	parts := strings.SplitN(locator, "/", 4)
+	if len(parts) < 3 {
+		return "", nil, fmt.Errorf("invalid package locator '%s'", packageUrl)
+	}
+	tempDir, err := os.MkdirTemp("", "avs-devnet-package-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "https://"+strings.Join(parts[:3], "/")+".git", tempDir)
+	output, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to clone package '%s': %w\n%s", packageUrl, err, output)
+	}
+	packageDir := tempDir
+	if len(parts) == 4 {
+		packageDir = filepath.Join(tempDir, filepath.FromSlash(parts[3]))
+	}
+	return packageDir, cleanup, nil
+}
+
+// Runs a Kurtosis package (or script) against the Docker backend.
+// Instructions are executed as soon as they're called, instead of being planned first like in Kurtosis.
+type planRunner struct {
+	ctx       context.Context
+	enclave   *dockerEnclave
+	responses chan<- progress_reporters.KurtosisResponse
+
+	predeclared starlark.StringDict
+	// Loaded modules, by file path
+	modules map[string]*starlarkstruct.Module
+	// Images built during this run
+	builtImages map[string]bool
+	// Number of instructions executed so far
+	step uint32
+	// Number of artifacts created without a name
+	unnamedArtifacts int
+}
+
+// Runs the `run` function of the main file at `packageDir/mainFile`, sending the progress to `responses`.
+// If `src` is given, it's used as the main file's contents. If `serializedParams` is given,
+// it's decoded as YAML and passed as the second argument.
+func runPlan(
+	ctx context.Context,
+	enclave *dockerEnclave,
+	packageDir string,
+	mainFile string,
+	src []byte,
+	serializedParams string,
+	responses chan<- progress_reporters.KurtosisResponse,
+) {
+	// Cancelled on return, which also stops the thread's cancellation watcher
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r := &planRunner{
+		ctx:         ctx,
+		enclave:     enclave,
+		responses:   responses,
+		modules:     make(map[string]*starlarkstruct.Module),
+		builtImages: make(map[string]bool),
+	}
+	r.predeclared = starlark.StringDict{
+		"struct":         starlark.NewBuiltin("struct", starlarkstruct.Make),
+		"json":           starlarkjson.Module,
+		"import_module":  starlark.NewBuiltin("import_module", r.importModule),
+		"ImageBuildSpec": starlark.NewBuiltin("ImageBuildSpec", newImageBuildSpec),
+		"ServiceConfig":  starlark.NewBuiltin("ServiceConfig", starlarkstruct.Make),
+		"PortSpec":       starlark.NewBuiltin("PortSpec", starlarkstruct.Make),
+		"User":           starlark.NewBuiltin("User", starlarkstruct.Make),
+		"StoreSpec":      starlark.NewBuiltin("StoreSpec", starlarkstruct.Make),
+		"Directory":      starlark.NewBuiltin("Directory", starlarkstruct.Make),
+		"ExecRecipe":     starlark.NewBuiltin("ExecRecipe", starlarkstruct.Make),
+	}
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromSinglelineProgressInfo(interpretationDescription, 0, 0))
+
+	thread := r.newThread()
+	mainPath := filepath.Join(packageDir, mainFile)
+	if src == nil {
+		var err error
+		src, err = os.ReadFile(mainPath)
+		if err != nil {
+			r.sendInterpretationError(err)
+			return
+		}
+	}
+	mainModule, err := r.execModule(thread, mainPath, src)
+	if err != nil {
+		r.sendInterpretationError(err)
+		return
+	}
+	runFn, ok := mainModule.Members["run"].(starlark.Callable)
+	if !ok {
+		r.sendInterpretationError(fmt.Errorf("'%s' has no 'run' function", mainFile))
+		return
+	}
+	args := starlark.Tuple{r.newPlan()}
+	if serializedParams != "" {
+		params, paramsErr := decodeParams(serializedParams)
+		if paramsErr != nil {
+			r.sendInterpretationError(paramsErr)
+			return
+		}
+		args = append(args, params)
+	}
+
+	// Total steps are unknown, since instructions are executed as they're called
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromSinglelineProgressInfo(executionDescription, 0, 0))
+	if _, err = starlark.Call(thread, runFn, args, nil); err != nil {
+		message := err.Error()
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			message = evalErr.Backtrace()
+		}
+		r.send(binding_constructors.NewStarlarkRunResponseLineFromExecutionError(
+			binding_constructors.NewStarlarkExecutionError(message),
+		))
+		return
+	}
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromRunSuccessEvent(""))
+}
+
+// Sends a response line, unless the run was cancelled.
+func (r *planRunner) send(response progress_reporters.KurtosisResponse) {
+	select {
+	case r.responses <- response:
+	case <-r.ctx.Done():
+	}
+}
+
+func (r *planRunner) sendInterpretationError(err error) {
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromInterpretationError(
+		binding_constructors.NewStarlarkInterpretationError(err.Error()),
+	))
+}
+
+func (r *planRunner) newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: "plan",
+		Print: func(_ *starlark.Thread, msg string) {
+			r.send(binding_constructors.NewStarlarkRunResponseLineFromInfoMsg(msg))
+		},
+	}
+	go func() {
+		<-r.ctx.Done()
+		thread.Cancel("run cancelled")
+	}()
+	return thread
+}
+
+// Executes a Starlark file, returning its globals as a module.
+func (r *planRunner) execModule(thread *starlark.Thread, filePath string, src []byte) (*starlarkstruct.Module, error) {
+	globals, err := starlark.ExecFileOptions(fileOptions, thread, filePath, src, r.predeclared)
+	if err != nil {
+		var resolveErrs resolve.ErrorList
+		if errors.As(err, &resolveErrs) {
+			return nil, fmt.Errorf("%s", resolveErrs.Error())
+		}
+		return nil, err
+	}
+	module := &starlarkstruct.Module{Name: strings.TrimSuffix(filepath.Base(filePath), ".star"), Members: globals}
+	return module, nil
+}
+
+// Implements `import_module(locator)`.
+// Relative locators are resolved from the calling file's dir.
+func (r *planRunner) importModule(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var locator string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &locator); err != nil {
+		return nil, err
+	}
+	var filePath string
+	var src []byte
+	switch {
+	case locator == ethereumPackageLocator:
+		// Executed as if it were in the importer's dir, to resolve its relative imports from there
+		filePath = filepath.Join(callerDir(thread), "docker_ethereum.star")
+		src = ethereumPackageSrc
+	case strings.HasPrefix(locator, "github.com/"):
+		return nil, fmt.Errorf("importing remote module '%s' is not supported by the Docker backend", locator)
+	default:
+		filePath = filepath.Join(callerDir(thread), locator)
+	}
+	if module, ok := r.modules[filePath]; ok {
+		return module, nil
+	}
+	if src == nil {
+		var err error
+		src, err = os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	module, err := r.execModule(thread, filePath, src)
+	if err != nil {
+		return nil, err
+	}
+	r.modules[filePath] = module
+	return module, nil
+}
+
+// Returns the dir of the file calling the current builtin.
+func callerDir(thread *starlark.Thread) string {
+	return filepath.Dir(thread.CallFrame(1).Pos.Filename())
+}
+
+// Implements `ImageBuildSpec(...)`, resolving the build context from the calling file's dir.
+func newImageBuildSpec(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("%s: unexpected positional arguments", fn.Name())
+	}
+	spec := starlarkstruct.FromKeywords(fn, kwargs)
+	fields := make(starlark.StringDict)
+	spec.ToStringDict(fields)
+	contextDir, ok := starlark.AsString(fields["build_context_dir"])
+	if !ok {
+		return nil, fmt.Errorf("%s: missing build_context_dir", fn.Name())
+	}
+	fields["build_context_dir"] = starlark.String(filepath.Join(callerDir(thread), contextDir))
+	return starlarkstruct.FromStringDict(fn, fields), nil
+}
+
+// Returns the `plan` object passed to the package's `run` function.
+func (r *planRunner) newPlan() starlark.Value {
+	methods := map[string]func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error){
+		"add_service":         r.addService,
+		"remove_service":      r.removeService,
+		"start_service":       r.startService,
+		"stop_service":        r.stopService,
+		"exec":                r.exec,
+		"run_sh":              r.runSh,
+		"render_templates":    r.renderTemplates,
+		"store_service_files": r.storeServiceFiles,
+		"verify":              r.verify,
+		"print":               r.print,
+	}
+	members := make(starlark.StringDict, len(methods))
+	for name, method := range methods {
+		members[name] = starlark.NewBuiltin(name, method)
+	}
+	return &starlarkstruct.Module{Name: "plan", Members: members}
+}
+
+// Reports the start of an instruction.
+func (r *planRunner) beginStep(thread *starlark.Thread, name string, description string) {
+	r.step += 1
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromSinglelineProgressInfo(description, r.step, 0))
+	pos := thread.CallFrame(1).Pos
+	position := binding_constructors.NewStarlarkInstructionPosition(pos.Filename(), pos.Line, pos.Col)
+	instruction := binding_constructors.NewStarlarkInstruction(position, name, name, nil, false, description)
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromInstruction(instruction))
+}
+
+// Reports the result of the current instruction.
+func (r *planRunner) endStep(result string) {
+	r.send(binding_constructors.NewStarlarkRunResponseLineFromInstructionResult(result))
+}
+
+func (r *planRunner) print(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var msg starlark.Value
+	var description string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "msg", &msg, "description?", &description); err != nil {
+		return nil, err
+	}
+	text, ok := starlark.AsString(msg)
+	if !ok {
+		text = msg.String()
+	}
+	r.beginStep(thread, fn.Name(), "Printing a message")
+	r.endStep(text)
+	return starlark.None, nil
+}
+
+func (r *planRunner) addService(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var name string
+	var config *starlarkstruct.Struct
+	var description string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "config", &config, "description?", &description)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = fmt.Sprintf("Adding service '%s'", name)
+	}
+	r.beginStep(thread, fn.Name(), description)
+	image, err := r.resolveImage(attr(config, "image"))
+	if err != nil {
+		return nil, err
+	}
+	createArgs := []string{
+		"create",
+		"--name", r.enclave.containerName(name),
+		"--hostname", name,
+		"--network", networkPrefix + r.enclave.name,
+		"--network-alias", name,
+		"--label", enclaveLabel + "=" + r.enclave.name,
+		"--label", serviceLabel + "=" + name,
+	}
+	ports, err := toStringDict(attr(config, "ports"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ports: %w", err)
+	}
+	portWaits := make(map[string]time.Duration)
+	portStructs := make(starlark.StringDict)
+	for _, portName := range maputil.SortedKeys(ports) {
+		spec, ok := ports[portName].(*starlarkstruct.Struct)
+		if !ok {
+			return nil, fmt.Errorf("port '%s' is not a PortSpec", portName)
+		}
+		containerPort, wait, portErr := parsePortSpec(spec)
+		if portErr != nil {
+			return nil, fmt.Errorf("port '%s': %w", portName, portErr)
+		}
+		createArgs = append(createArgs, "--label", portLabelPrefix+portName+"="+containerPort)
+		if _, ok := portWaits[containerPort]; !ok {
+			createArgs = append(createArgs, "--publish", "127.0.0.1::"+containerPort)
+		}
+		portWaits[containerPort] = max(portWaits[containerPort], wait)
+		portStructs[portName] = spec
+	}
+	envArgs, err := envVarArgs(attr(config, "env_vars"))
+	if err != nil {
+		return nil, err
+	}
+	createArgs = append(createArgs, envArgs...)
+	if user, ok := attr(config, "user").(*starlarkstruct.Struct); ok {
+		createArgs = append(createArgs, "--user", fmt.Sprintf("%s:%s", attr(user, "uid"), attr(user, "gid")))
+	}
+	entrypoint, err := toStringList(attr(config, "entrypoint"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid entrypoint: %w", err)
+	}
+	cmd, err := toStringList(attr(config, "cmd"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cmd: %w", err)
+	}
+	if len(entrypoint) != 0 {
+		createArgs = append(createArgs, "--entrypoint", entrypoint[0])
+	}
+	createArgs = append(createArgs, image)
+	if len(entrypoint) > 1 {
+		createArgs = append(createArgs, entrypoint[1:]...)
+	}
+	createArgs = append(createArgs, cmd...)
+
+	output, err := r.enclave.docker.Output(r.ctx, createArgs...)
+	if err != nil {
+		return nil, err
+	}
+	containerId := strings.TrimSpace(string(output))
+	if err = r.copyFilesInto(containerId, attr(config, "files")); err != nil {
+		return nil, err
+	}
+	if _, err = r.enclave.docker.Output(r.ctx, "start", containerId); err != nil {
+		return nil, err
+	}
+	info, err := r.waitForPorts(containerId, portWaits)
+	if err != nil {
+		return nil, fmt.Errorf("service '%s' failed to start: %w", name, err)
+	}
+	ipAddress := info.NetworkSettings.Networks[networkPrefix+r.enclave.name].IPAddress
+	r.endStep(fmt.Sprintf("Service '%s' added with IP '%s'", name, ipAddress))
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"name":       starlark.String(name),
+		"hostname":   starlark.String(name),
+		"ip_address": starlark.String(ipAddress),
+		"ports":      dictFrom(portStructs),
+	}), nil
+}
+
+// Returns the container port (e.g. "8545/tcp") and the time to wait for it to open.
+func parsePortSpec(spec *starlarkstruct.Struct) (string, time.Duration, error) {
+	number, ok := attr(spec, "number").(starlark.Int)
+	if !ok {
+		return "", 0, errors.New("missing port number")
+	}
+	protocol := "tcp"
+	if value, ok := starlark.AsString(attr(spec, "transport_protocol")); ok && value != "" {
+		protocol = strings.ToLower(value)
+	}
+	wait := defaultPortWait
+	switch value := attr(spec, "wait").(type) {
+	case nil:
+	case starlark.NoneType:
+		wait = 0
+	case starlark.String:
+		var err error
+		wait, err = time.ParseDuration(string(value))
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid wait: %w", err)
+		}
+	}
+	if protocol != "tcp" {
+		// Only TCP ports can be checked
+		wait = 0
+	}
+	return number.String() + "/" + protocol, wait, nil
+}
+
+// Waits until the container's ports accept connections, returning the container's info.
+// Fails if the container stops, or if the ports are still closed after their wait time.
+func (r *planRunner) waitForPorts(containerId string, portWaits map[string]time.Duration) (containerInfo, error) {
+	start := time.Now()
+	for {
+		output, err := r.enclave.docker.Output(r.ctx, "container", "inspect", containerId)
+		if err != nil {
+			return containerInfo{}, err
+		}
+		var infos []struct {
+			containerInfo
+			State struct{ Running bool }
+		}
+		if err = json.Unmarshal(output, &infos); err != nil || len(infos) != 1 {
+			return containerInfo{}, fmt.Errorf("failed to parse container info: %w", err)
+		}
+		info := infos[0]
+		if !info.State.Running {
+			logs, _ := r.enclave.docker.Output(r.ctx, "logs", "--tail", "20", containerId)
+			return containerInfo{}, fmt.Errorf("container exited. Last logs:\n%s", logs)
+		}
+		pending := ""
+		for containerPort, wait := range portWaits {
+			bindings := info.NetworkSettings.Ports[containerPort]
+			if wait == 0 || len(bindings) == 0 {
+				continue
+			}
+			endpoint := net.JoinHostPort(bindings[0].HostIp, bindings[0].HostPort)
+			conn, dialErr := net.DialTimeout("tcp", endpoint, time.Second)
+			if dialErr == nil {
+				_ = conn.Close()
+				delete(portWaits, containerPort)
+				continue
+			}
+			if time.Since(start) > wait {
+				return containerInfo{}, fmt.Errorf("port %s didn't open after %s", containerPort, wait)
+			}
+			pending = containerPort
+		}
+		if pending == "" {
+			return info.containerInfo, nil
+		}
+		select {
+		case <-r.ctx.Done():
+			return containerInfo{}, r.ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (r *planRunner) removeService(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	return r.serviceCommand(thread, fn, args, kwargs, "Removing service '%s'", "rm", "--force", "--volumes")
+}
+
+func (r *planRunner) startService(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	return r.serviceCommand(thread, fn, args, kwargs, "Starting service '%s'", "start")
+}
+
+func (r *planRunner) stopService(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	return r.serviceCommand(thread, fn, args, kwargs, "Stopping service '%s'", "stop")
+}
+
+// Runs a docker command taking the service's container as last argument.
+func (r *planRunner) serviceCommand(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+	descriptionFormat string,
+	dockerArgs ...string,
+) (starlark.Value, error) {
+	var name, description string
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "name", &name, "description?", &description); err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = fmt.Sprintf(descriptionFormat, name)
+	}
+	r.beginStep(thread, fn.Name(), description)
+	if _, err := r.enclave.docker.Output(r.ctx, append(dockerArgs, r.enclave.containerName(name))...); err != nil {
+		return nil, err
+	}
+	r.endStep(description + ": done")
+	return starlark.None, nil
+}
+
+func (r *planRunner) exec(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var serviceName, description string
+	var recipe *starlarkstruct.Struct
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"service_name", &serviceName, "recipe", &recipe, "description?", &description)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = fmt.Sprintf("Executing command on service '%s'", serviceName)
+	}
+	r.beginStep(thread, fn.Name(), description)
+	command, err := toStringList(attr(recipe, "command"))
+	if err != nil || len(command) == 0 {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	execArgs := append([]string{"exec", r.enclave.containerName(serviceName)}, command...)
+	output, code, err := r.enclave.docker.OutputWithExitCode(r.ctx, execArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("command exited with code %d: %s", code, output)
+	}
+	r.endStep(output)
+	result := starlark.NewDict(2)
+	_ = result.SetKey(starlark.String("output"), starlark.String(output))
+	_ = result.SetKey(starlark.String("code"), starlark.MakeInt(code))
+	return result, nil
+}
+
+func (r *planRunner) runSh(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var run, description string
+	var image, files, envVars, wait starlark.Value
+	var store *starlark.List
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"run", &run,
+		"image?", &image,
+		"files?", &files,
+		"store?", &store,
+		"env_vars?", &envVars,
+		"description?", &description,
+		"wait?", &wait,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = "Running shell command"
+	}
+	r.beginStep(thread, fn.Name(), description)
+	if image == nil {
+		image = starlark.String(defaultRunShImage)
+	}
+	imageName, err := r.resolveImage(image)
+	if err != nil {
+		return nil, err
+	}
+	createArgs := []string{
+		"create",
+		"--network", networkPrefix + r.enclave.name,
+		"--label", enclaveLabel + "=" + r.enclave.name,
+		"--entrypoint", "sh",
+	}
+	envArgs, err := envVarArgs(envVars)
+	if err != nil {
+		return nil, err
+	}
+	createArgs = append(createArgs, envArgs...)
+	createArgs = append(createArgs, imageName, "-c", run)
+	output, err := r.enclave.docker.Output(r.ctx, createArgs...)
+	if err != nil {
+		return nil, err
+	}
+	containerId := strings.TrimSpace(string(output))
+	defer func() {
+		// Use a new context, so the container is removed even when cancelled
+		_, _ = r.enclave.docker.Output(context.Background(), "rm", "--force", "--volumes", containerId)
+	}()
+	if err = r.copyFilesInto(containerId, files); err != nil {
+		return nil, err
+	}
+	runOutput, code, err := r.enclave.docker.OutputWithExitCode(r.ctx, "start", "--attach", containerId)
+	if err != nil {
+		return nil, err
+	}
+	if code != 0 {
+		return nil, fmt.Errorf("command exited with code %d: %s", code, runOutput)
+	}
+	if store != nil {
+		for i := range store.Len() {
+			spec, ok := store.Index(i).(*starlarkstruct.Struct)
+			if !ok {
+				return nil, errors.New("store entries must be StoreSpecs")
+			}
+			src, _ := starlark.AsString(attr(spec, "src"))
+			name, _ := starlark.AsString(attr(spec, "name"))
+			if err = r.storeFiles(containerId, src, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	r.endStep(runOutput)
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"output": starlark.String(runOutput),
+		"code":   starlark.MakeInt(code),
+	}), nil
+}
+
+func (r *planRunner) storeServiceFiles(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var serviceName, src, name, description string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"service_name", &serviceName, "src", &src, "name?", &name, "description?", &description)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = fmt.Sprintf("Storing files from service '%s'", serviceName)
+	}
+	r.beginStep(thread, fn.Name(), description)
+	if name == "" {
+		name = r.newArtifactName()
+	}
+	if err = r.storeFiles(r.enclave.containerName(serviceName), src, name); err != nil {
+		return nil, err
+	}
+	r.endStep(fmt.Sprintf("Files stored as artifact '%s'", name))
+	return starlark.String(name), nil
+}
+
+// Copies the file or the contents of the dir at `src` in the container into a new artifact.
+func (r *planRunner) storeFiles(container string, src string, artifactName string) error {
+	tempDir, err := os.MkdirTemp("", "avs-devnet-store-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	copied := filepath.Join(tempDir, "copied")
+	if _, err = r.enclave.docker.Output(r.ctx, "cp", container+":"+src, copied); err != nil {
+		return fmt.Errorf("failed to store '%s': %w", src, err)
+	}
+	dst, err := r.enclave.newArtifactDir(artifactName)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(copied)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fsutil.CopyFile(copied, filepath.Join(dst, path.Base(src)), info.Mode())
+	}
+	return fsutil.CopyDir(copied, dst, nil)
+}
+
+func (r *planRunner) renderTemplates(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var config *starlark.Dict
+	var name, description string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"config", &config, "name?", &name, "description?", &description)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = "Rendering templates"
+	}
+	r.beginStep(thread, fn.Name(), description)
+	if name == "" {
+		name = r.newArtifactName()
+	}
+	files, err := toStringDict(config)
+	if err != nil {
+		return nil, err
+	}
+	rendered := make(map[string][]byte, len(files))
+	for fileName, value := range files {
+		fileConfig, ok := value.(*starlarkstruct.Struct)
+		if !ok {
+			return nil, fmt.Errorf("config of '%s' must be a struct", fileName)
+		}
+		templateStr, _ := starlark.AsString(attr(fileConfig, "template"))
+		tmpl, parseErr := template.New(fileName).Parse(templateStr)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid template for '%s': %w", fileName, parseErr)
+		}
+		var buf bytes.Buffer
+		if execErr := tmpl.Execute(&buf, toGo(attr(fileConfig, "data"))); execErr != nil {
+			return nil, fmt.Errorf("failed to render '%s': %w", fileName, execErr)
+		}
+		rendered[fileName] = buf.Bytes()
+	}
+	dst, err := r.enclave.newArtifactDir(name)
+	if err != nil {
+		return nil, err
+	}
+	for fileName, contents := range rendered {
+		filePath := filepath.Join(dst, filepath.FromSlash(fileName))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(filePath, contents, 0600); err != nil {
+			return nil, err
+		}
+	}
+	r.endStep(fmt.Sprintf("Templates rendered to artifact '%s'", name))
+	return starlark.String(name), nil
+}
+
+func (r *planRunner) verify(
+	thread *starlark.Thread,
+	fn *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var value, targetValue starlark.Value
+	var assertion, description string
+	err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"value", &value, "assertion", &assertion, "target_value", &targetValue, "description?", &description)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		description = "Verifying value"
+	}
+	r.beginStep(thread, fn.Name(), description)
+	ops := map[string]syntax.Token{
+		"==": syntax.EQL, "!=": syntax.NEQ, "<": syntax.LT, "<=": syntax.LE, ">": syntax.GT, ">=": syntax.GE,
+	}
+	var ok bool
+	switch assertion {
+	case "IN", "NOT_IN":
+		ok, err = containsValue(targetValue, value)
+		ok = ok == (assertion == "IN")
+	default:
+		op, known := ops[assertion]
+		if !known {
+			return nil, fmt.Errorf("unknown assertion '%s'", assertion)
+		}
+		ok, err = starlark.Compare(op, value, targetValue)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("verification failed: %s %s %s", value, assertion, targetValue)
+	}
+	r.endStep("Verification succeeded")
+	return starlark.None, nil
+}
+
+func containsValue(container starlark.Value, value starlark.Value) (bool, error) {
+	iterable, ok := container.(starlark.Iterable)
+	if !ok {
+		return false, fmt.Errorf("'%s' is not iterable", container.Type())
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		if equal, err := starlark.Equal(item, value); err != nil || equal {
+			return equal, err
+		}
+	}
+	return false, nil
+}
+
+func (r *planRunner) newArtifactName() string {
+	r.unnamedArtifacts += 1
+	return fmt.Sprintf("artifact-%d", r.unnamedArtifacts)
+}
+
+// Returns the name of the image to use, building it first if it's an ImageBuildSpec.
+func (r *planRunner) resolveImage(image starlark.Value) (string, error) {
+	if name, ok := starlark.AsString(image); ok {
+		return name, nil
+	}
+	spec, ok := image.(*starlarkstruct.Struct)
+	if !ok {
+		return "", fmt.Errorf("invalid image '%s'", image)
+	}
+	imageName, _ := starlark.AsString(attr(spec, "image_name"))
+	// Docker doesn't allow uppercase letters in image names
+	imageName = strings.ToLower(imageName)
+	if r.builtImages[imageName] {
+		return imageName, nil
+	}
+	contextDir, _ := starlark.AsString(attr(spec, "build_context_dir"))
+	buildFile := "Dockerfile"
+	if value, ok := starlark.AsString(attr(spec, "build_file")); ok && value != "" {
+		buildFile = value
+	}
+	buildArgs := []string{"build", "--tag", imageName, "--file", filepath.Join(contextDir, buildFile)}
+	if target, ok := starlark.AsString(attr(spec, "target_stage")); ok && target != "" {
+		buildArgs = append(buildArgs, "--target", target)
+	}
+	args, err := toStringDict(attr(spec, "build_args"))
+	if err != nil {
+		return "", fmt.Errorf("invalid build args: %w", err)
+	}
+	for _, key := range maputil.SortedKeys(args) {
+		value, _ := starlark.AsString(args[key])
+		buildArgs = append(buildArgs, "--build-arg", key+"="+value)
+	}
+	buildArgs = append(buildArgs, contextDir)
+	if _, err = r.enclave.docker.Output(r.ctx, buildArgs...); err != nil {
+		return "", fmt.Errorf("failed to build image '%s': %w", imageName, err)
+	}
+	r.builtImages[imageName] = true
+	return imageName, nil
+}
+
+// Copies the artifacts into a created container.
+// `files` maps paths inside the container to an artifact name, or a Directory with multiple artifacts.
+func (r *planRunner) copyFilesInto(containerId string, files starlark.Value) error {
+	fileMap, err := toStringDict(files)
+	if err != nil || len(fileMap) == 0 {
+		return err
+	}
+	// Artifacts are laid out at their final paths, so a single copy creates any missing parent dirs
+	stagingDir, err := os.MkdirTemp("", "avs-devnet-files-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+	for _, dstPath := range maputil.SortedKeys(fileMap) {
+		artifactNames := make([]string, 0, 1)
+		switch value := fileMap[dstPath].(type) {
+		case starlark.String:
+			artifactNames = append(artifactNames, string(value))
+		case *starlarkstruct.Struct:
+			artifactNames, err = toStringList(attr(value, "artifact_names"))
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid files for '%s'", dstPath)
+		}
+		stagingPath := filepath.Join(stagingDir, filepath.FromSlash(dstPath))
+		if err = os.MkdirAll(stagingPath, 0755); err != nil {
+			return err
+		}
+		for _, artifactName := range artifactNames {
+			artifactDir, artifactErr := r.enclave.artifactDir(artifactName)
+			if artifactErr != nil {
+				return artifactErr
+			}
+			if err = fsutil.CopyDir(artifactDir, stagingPath, nil); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = r.enclave.docker.Output(r.ctx, "cp", stagingDir+"/.", containerId+":/")
+	return err
+}
+
+// Returns the `--env` args for the given env vars dict.
+func envVarArgs(envVars starlark.Value) ([]string, error) {
+	env, err := toStringDict(envVars)
+	if err != nil {
+		return nil, fmt.Errorf("invalid env vars: %w", err)
+	}
+	args := make([]string, 0, 2*len(env))
+	for _, key := range maputil.SortedKeys(env) {
+		value, ok := starlark.AsString(env[key])
+		if !ok {
+			value = env[key].String()
+		}
+		args = append(args, "--env", key+"="+value)
+	}
+	return args, nil
+}
+
+// Returns the attribute of the struct, or nil if it's not set.
+func attr(s *starlarkstruct.Struct, name string) starlark.Value {
+	value, err := s.Attr(name)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// Converts a dict with string keys into a StringDict. Nil and None values return an empty dict.
+func toStringDict(value starlark.Value) (starlark.StringDict, error) {
+	result := make(starlark.StringDict)
+	if value == nil || value == starlark.None {
+		return result, nil
+	}
+	dict, ok := value.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("expected dict, got %s", value.Type())
+	}
+	for _, item := range dict.Items() {
+		key, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("expected string key, got %s", item[0].Type())
+		}
+		result[key] = item[1]
+	}
+	return result, nil
+}
+
+// Converts a list of strings. Nil and None values return an empty list.
+func toStringList(value starlark.Value) ([]string, error) {
+	if value == nil || value == starlark.None {
+		return nil, nil
+	}
+	iterable, ok := value.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("expected list, got %s", value.Type())
+	}
+	result := make([]string, 0)
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		str, ok := starlark.AsString(item)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", item.Type())
+		}
+		result = append(result, str)
+	}
+	return result, nil
+}
+
+func dictFrom(values starlark.StringDict) *starlark.Dict {
+	dict := starlark.NewDict(len(values))
+	for key, value := range values {
+		_ = dict.SetKey(starlark.String(key), value)
+	}
+	return dict
+}
+
+// Converts a Starlark value into a Go value, for use as template data.
+func toGo(value starlark.Value) any {
+	switch value := value.(type) {
+	case nil, starlark.NoneType:
+		return nil
+	case starlark.String:
+		return string(value)
+	case starlark.Bool:
+		return bool(value)
+	case starlark.Int:
+		if i, ok := value.Int64(); ok {
+			return i
+		}
+		return value.BigInt()
+	case starlark.Float:
+		return float64(value)
+	case *starlark.Dict:
+		result := make(map[string]any, value.Len())
+		for _, item := range value.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				key = item[0].String()
+			}
+			result[key] = toGo(item[1])
+		}
+		return result
+	case *starlarkstruct.Struct:
+		fields := make(starlark.StringDict)
+		value.ToStringDict(fields)
+		result := make(map[string]any, len(fields))
+		for key, field := range fields {
+			result[key] = toGo(field)
+		}
+		return result
+	case starlark.Iterable:
+		result := make([]any, 0)
+		iter := value.Iterate()
+		defer iter.Done()
+		var item starlark.Value
+		for iter.Next(&item) {
+			result = append(result, toGo(item))
+		}
+		return result
+	default:
+		return value.String()
+	}
+}
+
+// Decodes the package's YAML params into Starlark values.
+func decodeParams(serializedParams string) (starlark.Value, error) {
+	var params any
+	if err := yaml.Unmarshal([]byte(serializedParams), &params); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if params == nil {
+		return starlark.NewDict(0), nil
+	}
+	return fromGo(params)
+}
+
+// Converts a value decoded from YAML into a Starlark value.
+func fromGo(value any) (starlark.Value, error) {
+	switch value := value.(type) {
+	case nil:
+		return starlark.None, nil
+	case string:
+		return starlark.String(value), nil
+	case bool:
+		return starlark.Bool(value), nil
+	case int:
+		return starlark.MakeInt(value), nil
+	case uint64:
+		return starlark.MakeUint64(value), nil
+	case *big.Int:
+		return starlark.MakeBigInt(value), nil
+	case float64:
+		return starlark.Float(value), nil
+	case []any:
+		items := make([]starlark.Value, 0, len(value))
+		for _, item := range value {
+			converted, err := fromGo(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, converted)
+		}
+		return starlark.NewList(items), nil
+	case map[string]any:
+		dict := starlark.NewDict(len(value))
+		for _, key := range maputil.SortedKeys(value) {
+			converted, err := fromGo(value[key])
+			if err != nil {
+				return nil, err
+			}
+			_ = dict.SetKey(starlark.String(key), converted)
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported param type %T", value)
+	}
+}
@@ -0,0 +1,712 @@
+package backend_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/internal/maputil"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// Container ID returned by the stub for created containers.
+const stubContainerId = "container-1"
+
+// Stands in for the docker CLI, recording the commands it receives.
+type stubDocker struct {
+	mu    sync.Mutex
+	calls [][]string
+	// Files copied into containers, by path inside the container
+	copied map[string]string
+	// Output and exit code of commands run inside containers
+	output   string
+	exitCode int
+}
+
+var _ backend.DockerExecutor = (*stubDocker)(nil)
+
+func newStubDocker() *stubDocker {
+	return &stubDocker{copied: make(map[string]string)}
+}
+
+func (s *stubDocker) Output(_ context.Context, args ...string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, args)
+	switch args[0] {
+	case "create":
+		return []byte(stubContainerId + "\n"), nil
+	case "container":
+		return []byte(`[{
+			"State": {"Running": true},
+			"NetworkSettings": {"Networks": {"avs-devnet-devnet": {"IPAddress": "172.16.0.2"}}}
+		}]`), nil
+	case "cp":
+		// Containers get their files from a staging dir
+		stagingDir, ok := strings.CutSuffix(args[1], "/.")
+		if !ok {
+			// Files copied out of containers are stored as an empty dir
+			return nil, os.MkdirAll(args[2], 0700)
+		}
+		err := filepath.WalkDir(stagingDir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(stagingDir, path)
+			s.copied["/"+filepath.ToSlash(relPath)] = string(contents)
+			return err
+		})
+		return nil, err
+	default:
+		return nil, nil
+	}
+}
+
+func (s *stubDocker) OutputWithExitCode(_ context.Context, args ...string) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, args)
+	return s.output, s.exitCode, nil
+}
+
+// Returns the recorded commands starting with the given docker subcommand.
+func (s *stubDocker) callsOf(subcommand string) [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([][]string, 0)
+	for _, call := range s.calls {
+		if call[0] == subcommand {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Returns an enclave named "devnet" of a Docker backend running commands with the stub.
+func newStubEnclave(t *testing.T, docker *stubDocker) backend.Enclave {
+	t.Helper()
+	d := backend.NewDockerWithExecutor(t.TempDir(), docker)
+	enclave, err := d.CreateEnclave(context.Background(), "devnet")
+	require.NoError(t, err)
+	return enclave
+}
+
+const mainStar = `
+lib = import_module("./lib.star")
+
+def run(plan, args):
+    config = plan.render_templates(
+        config={"config.txt": struct(template="{{.greeting}}", data={"greeting": lib.greeting(args["name"])})},
+        name="config",
+    )
+    node = plan.add_service(
+        name="node",
+        config=ServiceConfig(
+            image="alpine",
+            ports={"rpc": PortSpec(number=8545, wait=None)},
+            env_vars={"NAME": args["name"]},
+            files={"/config": config},
+            cmd=["sleep", "infinity"],
+        ),
+    )
+    result = plan.exec(service_name="node", recipe=ExecRecipe(command=["cat", "/config/config.txt"]))
+    plan.verify(value=result["code"], assertion="==", target_value=0)
+    plan.print("node at " + node.ip_address)
+`
+
+const libStar = `
+def greeting(name):
+    return "hello " + name
+`
+
+func TestRunPackage(t *testing.T) {
+	t.Parallel()
+	packageDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "main.star"), []byte(mainStar), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "lib.star"), []byte(libStar), 0600))
+	docker := newStubDocker()
+	docker.output = "hello world"
+	enclave := newStubEnclave(t, docker)
+
+	responses, cancel, err := enclave.RunPackage(context.Background(), packageDir, "name: world")
+	require.NoError(t, err)
+	defer cancel()
+	var messages []string
+	success := false
+	for response := range responses {
+		require.Nil(t, response.GetError(), "run failed: %v", response.GetError())
+		if info := response.GetInfo(); info != nil {
+			messages = append(messages, info.GetInfoMessage())
+		}
+		if result := response.GetInstructionResult(); result != nil {
+			messages = append(messages, result.GetSerializedInstructionResult())
+		}
+		if event := response.GetRunFinishedEvent(); event != nil {
+			success = event.GetIsRunSuccessful()
+		}
+	}
+	require.True(t, success)
+	require.Contains(t, messages, "node at 172.16.0.2")
+	require.Contains(t, messages, "hello world")
+
+	creates := docker.callsOf("create")
+	require.Len(t, creates, 1)
+	require.Subset(t, creates[0], []string{"devnet--node", "NAME=world", "127.0.0.1::8545/tcp", "alpine"})
+	require.Equal(t, []string{"sleep", "infinity"}, creates[0][len(creates[0])-2:])
+	require.Equal(t, map[string]string{"/config/config.txt": "hello world"}, docker.copied)
+	require.Equal(t, [][]string{{"start", stubContainerId}}, docker.callsOf("start"))
+	require.Equal(t, [][]string{{"exec", "devnet--node", "cat", "/config/config.txt"}}, docker.callsOf("exec"))
+}
+
+func TestRunPackageEthereumStandIn(t *testing.T) {
+	t.Parallel()
+	// The stand-in runs the package's own anvil.star
+	packageDir := t.TempDir()
+	for _, file := range []string{"anvil.star", "shared_utils.star"} {
+		contents, err := os.ReadFile(filepath.Join("..", "..", "kurtosis_package", file))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(packageDir, file), contents, 0600))
+	}
+	mainStar := `
+ethereum_package = import_module("github.com/ethpandaops/ethereum-package/main.star")
+
+def run(plan, args):
+    output = ethereum_package.run(plan, args)
+    plan.print(output.all_participants[0].el_context.rpc_http_url)
+`
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "main.star"), []byte(mainStar), 0600))
+	docker := newStubDocker()
+	enclave := newStubEnclave(t, docker)
+
+	args := "network_params:\n  network_id: '42'\n  seconds_per_slot: 2\n"
+	responses, cancel, err := enclave.RunPackage(context.Background(), packageDir, args)
+	require.NoError(t, err)
+	defer cancel()
+	var messages []string
+	for response := range responses {
+		require.Nil(t, response.GetError(), "run failed: %v", response.GetError())
+		if result := response.GetInstructionResult(); result != nil {
+			messages = append(messages, result.GetSerializedInstructionResult())
+		}
+	}
+	require.Contains(t, messages, "http://el-1-anvil:8545")
+
+	creates := docker.callsOf("create")
+	require.NotEmpty(t, creates)
+	require.Subset(t, creates[0], []string{"devnet--el-1-anvil", "--chain-id", "42", "--block-time", "2"})
+}
+
+// Runs a package with the stub, returning the results of its instructions.
+// Fails the test if the run fails.
+func runStubPackage(t *testing.T, enclave backend.Enclave, packageDir string, args string) []string {
+	t.Helper()
+	responses, cancel, err := enclave.RunPackage(context.Background(), packageDir, args)
+	require.NoError(t, err)
+	defer cancel()
+	var results []string
+	success := false
+	for response := range responses {
+		require.Nil(t, response.GetError(), "run failed: %v", response.GetError())
+		if result := response.GetInstructionResult(); result != nil {
+			results = append(results, result.GetSerializedInstructionResult())
+		}
+		if event := response.GetRunFinishedEvent(); event != nil {
+			success = event.GetIsRunSuccessful()
+		}
+	}
+	require.True(t, success)
+	return results
+}
+
+func TestRunPackageEthereumStandInIgnoredArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		args    string
+		ignored string
+	}{
+		{
+			name: "supported args",
+			args: "network_params:\n  network_id: '42'\n  seconds_per_slot: 2\nparticipants:\n  - el_type: reth\n",
+		},
+		{
+			name: "ignored args",
+			args: "additional_services: [blockscout]\nnetwork_params:\n  preset: minimal\n  seconds_per_slot: 2\n" +
+				"participants:\n  - el_type: reth\n    el_extra_params: [--foo]\n  - el_type: geth\n",
+			ignored: "additional_services, network_params.preset, participants[1:], participants[0].el_extra_params",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			packageDir := t.TempDir()
+			for _, file := range []string{"anvil.star", "shared_utils.star"} {
+				contents, err := os.ReadFile(filepath.Join("..", "..", "kurtosis_package", file))
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(filepath.Join(packageDir, file), contents, 0600))
+			}
+			mainStar := `
+ethereum_package = import_module("github.com/ethpandaops/ethereum-package/main.star")
+
+def run(plan, args):
+    ethereum_package.run(plan, args)
+`
+			require.NoError(t, os.WriteFile(filepath.Join(packageDir, "main.star"), []byte(mainStar), 0600))
+			enclave := newStubEnclave(t, newStubDocker())
+
+			results := runStubPackage(t, enclave, packageDir, tc.args)
+			var warnings []string
+			for _, result := range results {
+				if strings.HasPrefix(result, "WARNING") {
+					warnings = append(warnings, result)
+				}
+			}
+			if tc.ignored == "" {
+				require.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			require.True(t, strings.HasSuffix(warnings[0], "args are ignored: "+tc.ignored), warnings[0])
+		})
+	}
+}
+
+// Runs the devnet package with each example config, checking the Docker backend supports everything it uses.
+// Artifacts uploaded by the CLI before running the package are replaced by empty ones.
+func TestRunPackageExamples(t *testing.T) {
+	t.Parallel()
+	examplePaths, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, examplePaths)
+	packageDir, err := filepath.Abs(filepath.Join("..", "..", "kurtosis_package"))
+	require.NoError(t, err)
+
+	for _, examplePath := range examplePaths {
+		t.Run(filepath.Base(examplePath), func(t *testing.T) {
+			t.Parallel()
+			devnetConfig, err := config.LoadFromPath(examplePath)
+			require.NoError(t, err)
+			docker := newStubDocker()
+			docker.output = "{}"
+			enclave := newStubEnclave(t, docker)
+			// Artifacts with static files, and the scripts of local repos
+			var uploadedArtifacts []string
+			for _, artifactName := range maputil.SortedKeys(devnetConfig.Artifacts) {
+				for _, file := range devnetConfig.Artifacts[artifactName].Files {
+					if file.IsStatic() {
+						uploadedArtifacts = append(uploadedArtifacts, artifactName)
+						break
+					}
+				}
+			}
+			for _, deployment := range devnetConfig.Deployments {
+				uploadedArtifacts = append(uploadedArtifacts, deployment.Name+"-script")
+			}
+			for _, artifactName := range uploadedArtifacts {
+				require.NoError(t, enclave.UploadFiles(context.Background(), t.TempDir(), artifactName))
+			}
+
+			runStubPackage(t, enclave, packageDir, string(devnetConfig.Marshal()))
+			// Services the package removes, like the one generating keys, aren't kept
+			removedContainers := make(map[string]bool)
+			for _, call := range docker.callsOf("rm") {
+				removedContainers[call[len(call)-1]] = true
+			}
+			var services []string
+			for _, call := range docker.callsOf("create") {
+				if call[1] == "--name" && !removedContainers[call[2]] {
+					services = append(services, strings.TrimPrefix(call[2], "devnet--"))
+				}
+			}
+			expectedServices := []string{"el-1-anvil"}
+			for _, service := range devnetConfig.Services {
+				expectedServices = append(expectedServices, service.Name)
+			}
+			require.ElementsMatch(t, expectedServices, services)
+		})
+	}
+}
+
+func TestRunPackageFailingExec(t *testing.T) {
+	t.Parallel()
+	docker := newStubDocker()
+	docker.output = "no such file"
+	docker.exitCode = 1
+	enclave := newStubEnclave(t, docker)
+
+	err := enclave.RunScript(context.Background(), `
+def run(plan):
+    plan.exec(service_name="node", recipe=ExecRecipe(command=["cat", "missing"]))
+`)
+	require.ErrorContains(t, err, "command exited with code 1: no such file")
+}
+
+func TestRenderTemplates(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		config   string
+		expected []backend.ArtifactFile
+		err      string
+	}{
+		{
+			name:     "value",
+			config:   `{"file.txt": struct(template="hello {{.name}}", data={"name": "world"})}`,
+			expected: []backend.ArtifactFile{{Path: "file.txt", TextPreview: "hello world"}},
+		},
+		{
+			name:     "list and number",
+			config:   `{"file.txt": struct(template="{{range .items}}{{.}},{{end}}", data={"items": [1, 2]})}`,
+			expected: []backend.ArtifactFile{{Path: "file.txt", TextPreview: "1,2,"}},
+		},
+		{
+			name:     "struct data",
+			config:   `{"file.txt": struct(template="{{.inner.key}}", data=struct(inner=struct(key="value")))}`,
+			expected: []backend.ArtifactFile{{Path: "file.txt", TextPreview: "value"}},
+		},
+		{
+			name: "nested files",
+			config: `{
+				"a.txt": struct(template="a", data={}),
+				"dir/b.txt": struct(template="b", data={}),
+			}`,
+			expected: []backend.ArtifactFile{{Path: "a.txt", TextPreview: "a"}, {Path: "dir/b.txt", TextPreview: "b"}},
+		},
+		{
+			name:   "invalid template",
+			config: `{"file.txt": struct(template="{{.name", data={})}`,
+			err:    "invalid template for 'file.txt'",
+		},
+		{
+			name:   "not a struct",
+			config: `{"file.txt": "hello"}`,
+			err:    "config of 'file.txt' must be a struct",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			enclave := newStubEnclave(t, newStubDocker())
+			script := "def run(plan):\n    plan.render_templates(config=" + tc.config + `, name="rendered")`
+			err := enclave.RunScript(context.Background(), script)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			files, err := enclave.InspectArtifact(context.Background(), "rendered")
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, files)
+		})
+	}
+}
+
+func TestCopyFilesInto(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		files    string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "no files",
+			files:    "{}",
+			expected: map[string]string{},
+		},
+		{
+			name:     "single artifact",
+			files:    `{"/data": "one"}`,
+			expected: map[string]string{"/data/one.txt": "1"},
+		},
+		{
+			name:  "multiple paths",
+			files: `{"/data": "one", "/root/config": "nested"}`,
+			expected: map[string]string{
+				"/data/one.txt":             "1",
+				"/root/config/dir/deep.txt": "deep",
+			},
+		},
+		{
+			name:     "directory with multiple artifacts",
+			files:    `{"/data": Directory(artifact_names=["one", "two"])}`,
+			expected: map[string]string{"/data/one.txt": "1", "/data/two.txt": "2"},
+		},
+		{
+			name:  "missing artifact",
+			files: `{"/data": "missing"}`,
+			err:   "artifact 'missing' not found",
+		},
+		{
+			name:  "invalid value",
+			files: `{"/data": 1}`,
+			err:   "invalid files for '/data'",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			docker := newStubDocker()
+			enclave := newStubEnclave(t, docker)
+			script := `
+def run(plan):
+    plan.render_templates(config={"one.txt": struct(template="1", data={})}, name="one")
+    plan.render_templates(config={"two.txt": struct(template="2", data={})}, name="two")
+    plan.render_templates(config={"dir/deep.txt": struct(template="deep", data={})}, name="nested")
+    plan.run_sh(run="true", files=` + tc.files + `)
+`
+			err := enclave.RunScript(context.Background(), script)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, docker.copied)
+			// The container is removed after running
+			require.Equal(t, [][]string{{"rm", "--force", "--volumes", stubContainerId}}, docker.callsOf("rm"))
+		})
+	}
+}
+
+func TestDecodeParams(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		params   string
+		expected string
+		err      bool
+	}{
+		{name: "empty", params: "", expected: "{}"},
+		{name: "string", params: "name: world", expected: `{"name": "world"}`},
+		{
+			name:     "scalars",
+			params:   "items: [1, true, 2.5, null, text]",
+			expected: `{"items": [1, True, 2.5, None, "text"]}`,
+		},
+		{name: "nested", params: "a: {c: 1, b: 2}", expected: `{"a": {"b": 2, "c": 1}}`},
+		{name: "big int", params: "n: 18446744073709551615", expected: `{"n": 18446744073709551615}`},
+		{name: "invalid", params: "a: [", err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			value, err := backend.DecodeParams(tc.params)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, value.String())
+		})
+	}
+}
+
+func TestGoStarlarkRoundTrip(t *testing.T) {
+	t.Parallel()
+	maxUint64 := new(big.Int).SetUint64(^uint64(0))
+	tests := []struct {
+		name  string
+		value any
+		// Expected value after converting back, if it differs
+		expected any
+	}{
+		{name: "nil", value: nil},
+		{name: "string", value: "text"},
+		{name: "bool", value: true},
+		{name: "int", value: 1, expected: int64(1)},
+		{name: "uint64", value: ^uint64(0), expected: maxUint64},
+		{name: "big int", value: maxUint64},
+		{name: "float", value: 2.5},
+		{name: "list", value: []any{"a", false, nil}},
+		{
+			name:     "map",
+			value:    map[string]any{"list": []any{1}, "inner": map[string]any{"key": "value"}},
+			expected: map[string]any{"list": []any{int64(1)}, "inner": map[string]any{"key": "value"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			value, err := backend.FromGo(tc.value)
+			require.NoError(t, err)
+			expected := tc.expected
+			if expected == nil {
+				expected = tc.value
+			}
+			require.Equal(t, expected, backend.ToGo(value))
+		})
+	}
+}
+
+func TestFromGoUnsupportedType(t *testing.T) {
+	t.Parallel()
+	_, err := backend.FromGo(time.Second)
+	require.ErrorContains(t, err, "unsupported param type")
+}
+
+func TestToGoStruct(t *testing.T) {
+	t.Parallel()
+	value := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"name":  starlark.String("node"),
+		"ports": starlark.NewList([]starlark.Value{starlark.MakeInt(8545)}),
+	})
+	require.Equal(t, map[string]any{"name": "node", "ports": []any{int64(8545)}}, backend.ToGo(value))
+}
+
+func TestParsePortSpec(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		fields       starlark.StringDict
+		expectedPort string
+		expectedWait time.Duration
+		err          string
+	}{
+		{
+			name:         "default wait",
+			fields:       starlark.StringDict{"number": starlark.MakeInt(8545)},
+			expectedPort: "8545/tcp",
+			expectedWait: 15 * time.Second,
+		},
+		{
+			name:         "custom wait",
+			fields:       starlark.StringDict{"number": starlark.MakeInt(8545), "wait": starlark.String("2m")},
+			expectedPort: "8545/tcp",
+			expectedWait: 2 * time.Minute,
+		},
+		{
+			name:         "no wait",
+			fields:       starlark.StringDict{"number": starlark.MakeInt(8545), "wait": starlark.None},
+			expectedPort: "8545/tcp",
+		},
+		{
+			name: "udp isn't waited for",
+			fields: starlark.StringDict{
+				"number":             starlark.MakeInt(9000),
+				"transport_protocol": starlark.String("UDP"),
+			},
+			expectedPort: "9000/udp",
+		},
+		{
+			name:   "invalid wait",
+			fields: starlark.StringDict{"number": starlark.MakeInt(8545), "wait": starlark.String("soon")},
+			err:    "invalid wait",
+		},
+		{
+			name:   "missing number",
+			fields: starlark.StringDict{"transport_protocol": starlark.String("TCP")},
+			err:    "missing port number",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			spec := starlarkstruct.FromStringDict(starlarkstruct.Default, tc.fields)
+			port, wait, err := backend.ParsePortSpec(spec)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPort, port)
+			require.Equal(t, tc.expectedWait, wait)
+		})
+	}
+}
+
+func TestEnvVarArgs(t *testing.T) {
+	t.Parallel()
+	envVars := starlark.NewDict(3)
+	require.NoError(t, envVars.SetKey(starlark.String("B"), starlark.String("2")))
+	require.NoError(t, envVars.SetKey(starlark.String("A"), starlark.String("x=1")))
+	require.NoError(t, envVars.SetKey(starlark.String("N"), starlark.MakeInt(3)))
+	tests := []struct {
+		name     string
+		envVars  starlark.Value
+		expected []string
+		err      bool
+	}{
+		{name: "unset", envVars: nil, expected: []string{}},
+		{name: "none", envVars: starlark.None, expected: []string{}},
+		{name: "sorted", envVars: envVars, expected: []string{"--env", "A=x=1", "--env", "B=2", "--env", "N=3"}},
+		{name: "not a dict", envVars: starlark.NewList(nil), err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := backend.EnvVarArgs(tc.envVars)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, args)
+		})
+	}
+}
+
+func TestPublishedPorts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		inspect  string
+		expected map[string]string
+	}{
+		{
+			name: "published",
+			inspect: `{
+				"Config": {"Labels": {
+					"com.layr-labs.avs-devnet.port.rpc": "8545/tcp",
+					"com.layr-labs.avs-devnet.port.ws": "8546/tcp",
+					"com.layr-labs.avs-devnet.service": "node"
+				}},
+				"NetworkSettings": {"Ports": {
+					"8545/tcp": [{"HostIp": "127.0.0.1", "HostPort": "32768"}],
+					"8546/tcp": [{"HostIp": "127.0.0.1", "HostPort": "32769"}]
+				}}
+			}`,
+			expected: map[string]string{"rpc": "127.0.0.1:32768", "ws": "127.0.0.1:32769"},
+		},
+		{
+			name: "shared port",
+			inspect: `{
+				"Config": {"Labels": {
+					"com.layr-labs.avs-devnet.port.http": "8080/tcp",
+					"com.layr-labs.avs-devnet.port.metrics": "8080/tcp"
+				}},
+				"NetworkSettings": {"Ports": {"8080/tcp": [{"HostIp": "127.0.0.1", "HostPort": "32770"}]}}
+			}`,
+			expected: map[string]string{"http": "127.0.0.1:32770", "metrics": "127.0.0.1:32770"},
+		},
+		{
+			name: "not published",
+			inspect: `{
+				"Config": {"Labels": {"com.layr-labs.avs-devnet.port.rpc": "8545/tcp"}},
+				"NetworkSettings": {"Ports": {"8545/tcp": []}}
+			}`,
+			expected: map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var info backend.ContainerInfo
+			require.NoError(t, json.Unmarshal([]byte(tc.inspect), &info))
+			require.Equal(t, tc.expected, info.PublishedPorts())
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package backend
+
+// Exposes internals of the package to its external tests.
+
+type (
+	DockerExecutor = dockerExecutor
+	ContainerInfo  = containerInfo
+)
+
+var (
+	DecodeParams  = decodeParams
+	FromGo        = fromGo
+	ToGo          = toGo
+	ParsePortSpec = parsePortSpec
+	EnvVarArgs    = envVarArgs
+)
+
+// Returns a Docker backend storing its artifacts in `stateDir`, running commands with `docker`.
+func NewDockerWithExecutor(stateDir string, docker DockerExecutor) *Docker {
+	return &Docker{stateDir: stateDir, docker: docker}
+}
+
+func (c ContainerInfo) PublishedPorts() map[string]string {
+	return c.publishedPorts()
+}
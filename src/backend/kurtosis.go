@@ -0,0 +1,153 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/enclaves"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/services"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/starlark_run_config"
+)
+
+// Backend running devnets inside Kurtosis enclaves.
+type Kurtosis struct {
+	kurtosisCtx kurtosis.KurtosisCtx
+}
+
+// A Kurtosis enclave.
+type kurtosisEnclave struct {
+	kurtosisCtx kurtosis.KurtosisCtx
+	enclaveCtx  *enclaves.EnclaveContext
+}
+
+// Connects to the local Kurtosis engine, starting it if it's not running.
+func NewKurtosis() (*Kurtosis, error) {
+	kurtosisCtx, err := kurtosis.InitKurtosisContext()
+	if err != nil {
+		return nil, err
+	}
+	return &Kurtosis{kurtosisCtx: kurtosisCtx}, nil
+}
+
+func (k *Kurtosis) EnclaveExists(ctx context.Context, name string) bool {
+	return k.kurtosisCtx.EnclaveExists(ctx, name)
+}
+
+func (k *Kurtosis) CreateEnclave(ctx context.Context, name string) (Enclave, error) {
+	enclaveCtx, err := k.kurtosisCtx.CreateEnclave(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &kurtosisEnclave{kurtosisCtx: k.kurtosisCtx, enclaveCtx: enclaveCtx}, nil
+}
+
+func (k *Kurtosis) GetEnclave(ctx context.Context, name string) (Enclave, error) {
+	enclaveCtx, err := k.kurtosisCtx.GetEnclaveCtx(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &kurtosisEnclave{kurtosisCtx: k.kurtosisCtx, enclaveCtx: enclaveCtx.EnclaveContext}, nil
+}
+
+func (k *Kurtosis) DestroyEnclave(ctx context.Context, name string) error {
+	return k.kurtosisCtx.DestroyEnclave(ctx, name)
+}
+
+func (e *kurtosisEnclave) Name() string {
+	return e.enclaveCtx.GetEnclaveName()
+}
+
+func (e *kurtosisEnclave) UploadFiles(_ context.Context, path string, artifactName string) error {
+	_, _, err := e.enclaveCtx.UploadFiles(path, artifactName)
+	return err
+}
+
+func (e *kurtosisEnclave) RunPackage(
+	ctx context.Context,
+	packageUrl string,
+	serializedParams string,
+) (chan progress_reporters.KurtosisResponse, context.CancelFunc, error) {
+	starlarkConfig := starlark_run_config.NewRunStarlarkConfig()
+	starlarkConfig.SerializedParams = serializedParams
+	if strings.HasPrefix(packageUrl, "github.com/") {
+		return e.enclaveCtx.RunStarlarkRemotePackage(ctx, packageUrl, starlarkConfig)
+	}
+	return e.enclaveCtx.RunStarlarkPackage(ctx, packageUrl, starlarkConfig)
+}
+
+func (e *kurtosisEnclave) RunScript(ctx context.Context, script string) error {
+	runConfig := starlark_run_config.NewRunStarlarkConfig()
+	_, err := e.enclaveCtx.RunStarlarkScriptBlocking(ctx, script, runConfig)
+	return err
+}
+
+func (e *kurtosisEnclave) InspectArtifact(ctx context.Context, artifactName string) ([]ArtifactFile, error) {
+	artifactInfo, err := e.enclaveCtx.InspectFilesArtifact(ctx, services.FileArtifactName(artifactName))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]ArtifactFile, 0, len(artifactInfo.GetFileDescriptions()))
+	for _, file := range artifactInfo.GetFileDescriptions() {
+		files = append(files, ArtifactFile{Path: file.GetPath(), TextPreview: file.GetTextPreview()})
+	}
+	return files, nil
+}
+
+// Downloads the artifact, which Kurtosis returns as a gzipped tarball, and extracts it.
+func (e *kurtosisEnclave) DownloadArtifact(ctx context.Context, artifactName string, dstDir string) error {
+	contents, err := e.enclaveCtx.DownloadFilesArtifact(ctx, artifactName)
+	if err != nil {
+		return err
+	}
+	return fsutil.ExtractTarGz(bytes.NewReader(contents), dstDir)
+}
+
+func (e *kurtosisEnclave) ListArtifacts(ctx context.Context) ([]string, error) {
+	artifacts, err := e.enclaveCtx.GetAllFilesArtifactNamesAndUuids(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		names = append(names, artifact.GetFileName())
+	}
+	return names, nil
+}
+
+func (e *kurtosisEnclave) ListServices(_ context.Context) ([]Service, error) {
+	serviceUuids, err := e.enclaveCtx.GetServices()
+	if err != nil {
+		return nil, err
+	}
+	identifiers := make(map[string]bool, len(serviceUuids))
+	for _, uuid := range serviceUuids {
+		identifiers[string(uuid)] = true
+	}
+	serviceCtxs, err := e.enclaveCtx.GetServiceContexts(identifiers)
+	if err != nil {
+		return nil, err
+	}
+	serviceList := make([]Service, 0, len(serviceCtxs))
+	for _, serviceCtx := range serviceCtxs {
+		ports := make(map[string]string)
+		ipAddr := serviceCtx.GetMaybePublicIPAddress()
+		for portName, port := range serviceCtx.GetPublicPorts() {
+			ports[portName] = fmt.Sprintf("%s:%d", ipAddr, port.GetNumber())
+		}
+		serviceList = append(serviceList, Service{
+			Name:      string(serviceCtx.GetServiceName()),
+			IPAddress: serviceCtx.GetPrivateIPAddress(),
+			Ports:     ports,
+		})
+	}
+	return serviceList, nil
+}
+
+func (e *kurtosisEnclave) ServiceLogs(ctx context.Context, numLines uint32) (map[string][]string, error) {
+	return e.kurtosisCtx.CollectServiceLogs(ctx, e.Name(), numLines)
+}
@@ -0,0 +1,150 @@
+package cmds
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+)
+
+var ErrUnsupportedArchive = errors.New("unsupported archive format, expected .tar.gz, .tgz or .zip")
+
+// Returns the file name of the archive at the given URL, used to detect its format.
+func archiveName(rawUrl string) string {
+	srcUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return path.Base(rawUrl)
+	}
+	return path.Base(srcUrl.Path)
+}
+
+// Extracts the archive into the destination dir.
+// The archive's format is detected from its name's extension.
+// Only regular files and dirs are extracted, and entries outside the destination dir are rejected.
+func extractArchive(archivePath string, name string, dstDir string) error {
+	err := os.MkdirAll(dstDir, 0700)
+	if err != nil {
+		return fmt.Errorf("output dir creation failed: %w", err)
+	}
+	lowerName := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lowerName, ".tar.gz"), strings.HasSuffix(lowerName, ".tgz"):
+		err = extractTarGz(archivePath, dstDir)
+	case strings.HasSuffix(lowerName, ".zip"):
+		err = extractZip(archivePath, dstDir)
+	default:
+		return fmt.Errorf("%w: '%s'", ErrUnsupportedArchive, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract '%s': %w", name, err)
+	}
+	return nil
+}
+
+// Extracts a gzipped tarball into the destination dir.
+func extractTarGz(archivePath string, dstDir string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return fsutil.ExtractTarGz(file, dstDir)
+}
+
+// Extracts a zip archive into the destination dir.
+func extractZip(archivePath string, dstDir string) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	for _, zipFile := range zipReader.File {
+		var dstPath string
+		dstPath, err = fsutil.ArchiveEntryPath(dstDir, zipFile.Name)
+		if err != nil {
+			return err
+		}
+		mode := zipFile.Mode()
+		if mode.IsDir() {
+			err = os.MkdirAll(dstPath, 0700)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+		var contents io.ReadCloser
+		contents, err = zipFile.Open()
+		if err != nil {
+			return err
+		}
+		err = fsutil.WriteFile(dstPath, contents, mode)
+		contents.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes the contents of the source dir into a gzipped tarball, with paths relative to the dir.
+// Only regular files and dirs are archived.
+func createTarGz(srcDir string, archivePath string) (err error) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, file.Close()) }()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err = filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil || path == srcDir {
+			return walkErr
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return infoErr
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, relErr := filepath.Rel(srcDir, path)
+		if relErr != nil {
+			return relErr
+		}
+		header, headerErr := tar.FileInfoHeader(info, "")
+		if headerErr != nil {
+			return headerErr
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if writeErr := tarWriter.WriteHeader(header); writeErr != nil || info.IsDir() {
+			return writeErr
+		}
+		contents, openErr := os.Open(path)
+		if openErr != nil {
+			return openErr
+		}
+		defer contents.Close()
+		_, copyErr := io.Copy(tarWriter, contents)
+		return copyErr
+	})
+	if err != nil {
+		return err
+	}
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
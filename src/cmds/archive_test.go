@@ -0,0 +1,217 @@
+package cmds_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/stretchr/testify/require"
+)
+
+// An entry of an archive built by the tests.
+type archiveEntry struct {
+	name     string
+	contents string
+	// Target of symlink entries
+	linkTarget string
+	isDir      bool
+}
+
+// Returns a gzipped tarball with the given entries.
+func tarGzWith(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.contents))}
+		switch {
+		case entry.isDir:
+			header = &tar.Header{Name: entry.name, Mode: 0755, Typeflag: tar.TypeDir}
+		case entry.linkTarget != "":
+			header = &tar.Header{Name: entry.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: entry.linkTarget}
+		}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// Returns a zip archive with the given entries.
+func zipWith(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		contents := entry.contents
+		switch {
+		case entry.isDir:
+			header.SetMode(fs.ModeDir | 0755)
+		case entry.linkTarget != "":
+			header.SetMode(fs.ModeSymlink | 0777)
+			contents = entry.linkTarget
+		default:
+			header.SetMode(0644)
+		}
+		writer, err := zipWriter.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestExtractArchive(t *testing.T) {
+	t.Parallel()
+	formats := []struct {
+		extension string
+		create    func(t *testing.T, entries ...archiveEntry) []byte
+	}{
+		{extension: ".tar.gz", create: tarGzWith},
+		{extension: ".zip", create: zipWith},
+	}
+	tests := []struct {
+		name     string
+		entries  []archiveEntry
+		expected map[string]string
+		err      string
+	}{
+		{
+			name: "files and dirs",
+			entries: []archiveEntry{
+				{name: "abi/", isDir: true},
+				{name: "abi/Contract.json", contents: "{}"},
+				{name: "nested/dir/file.txt", contents: "nested"},
+			},
+			expected: map[string]string{"abi/Contract.json": "{}", "nested/dir/file.txt": "nested"},
+		},
+		{
+			name:    "parent dir",
+			entries: []archiveEntry{{name: "../escaped.txt", contents: "escaped"}},
+			err:     "is outside the destination dir",
+		},
+		{
+			name:    "nested parent dir",
+			entries: []archiveEntry{{name: "dir/../../escaped.txt", contents: "escaped"}},
+			err:     "is outside the destination dir",
+		},
+		{
+			name:     "absolute path",
+			entries:  []archiveEntry{{name: "/etc/file.txt", contents: "absolute"}},
+			expected: map[string]string{"etc/file.txt": "absolute"},
+		},
+		{
+			name: "symlinks are skipped",
+			entries: []archiveEntry{
+				{name: "link", linkTarget: "/etc/passwd"},
+				{name: "file.txt", contents: "file"},
+			},
+			expected: map[string]string{"file.txt": "file"},
+		},
+	}
+	for _, format := range formats {
+		for _, tc := range tests {
+			t.Run(format.extension+"/"+tc.name, func(t *testing.T) {
+				t.Parallel()
+				tempDir := t.TempDir()
+				archivePath := filepath.Join(tempDir, "archive")
+				require.NoError(t, os.WriteFile(archivePath, format.create(t, tc.entries...), 0600))
+				dstDir := filepath.Join(tempDir, "dst")
+
+				err := cmds.ExtractArchive(archivePath, "bundle"+format.extension, dstDir)
+				require.NoFileExists(t, filepath.Join(tempDir, "escaped.txt"))
+				if tc.err != "" {
+					require.ErrorContains(t, err, tc.err)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, regularFiles(t, dstDir))
+			})
+		}
+	}
+}
+
+func TestExtractArchiveFormats(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "bundle.tgz"},
+		{name: "BUNDLE.TAR.GZ"},
+		{name: "bundle.tar", err: cmds.ErrUnsupportedArchive},
+		{name: "bundle.rar", err: cmds.ErrUnsupportedArchive},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tempDir := t.TempDir()
+			archivePath := filepath.Join(tempDir, "archive")
+			require.NoError(t, os.WriteFile(archivePath, tarGzWith(t, archiveEntry{name: "a.txt", contents: "a"}), 0600))
+			err := cmds.ExtractArchive(archivePath, tc.name, filepath.Join(tempDir, "dst"))
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestCreateTarGzRoundTrip(t *testing.T) {
+	t.Parallel()
+	srcDir := t.TempDir()
+	files := map[string]string{
+		"chain/state.json":          `{"block": 1}`,
+		"artifacts/config/app.yaml": "key: value",
+		"snapshot.json":             "{}",
+	}
+	for relPath, contents := range files {
+		filePath := filepath.Join(srcDir, filepath.FromSlash(relPath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		require.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(srcDir, "snapshot.json"), 0755))
+	// Symlinks aren't archived
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(srcDir, "link")))
+
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "snapshot.tar.gz")
+	require.NoError(t, cmds.CreateTarGz(srcDir, archivePath))
+	dstDir := filepath.Join(tempDir, "extracted")
+	require.NoError(t, cmds.ExtractArchive(archivePath, "snapshot.tar.gz", dstDir))
+
+	require.Equal(t, files, regularFiles(t, dstDir))
+	info, err := os.Stat(filepath.Join(dstDir, "snapshot.json"))
+	require.NoError(t, err)
+	require.Equal(t, fs.FileMode(0755), info.Mode().Perm())
+}
+
+// Returns the contents of the regular files inside the dir, by slash-separated path.
+// Fails if there are other kinds of files, like symlinks.
+func regularFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		require.True(t, entry.Type().IsRegular(), "'%s' isn't a regular file", path)
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(relPath)] = string(contents)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
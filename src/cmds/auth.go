@@ -0,0 +1,261 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/config"
+)
+
+var ErrMissingCredentials = errors.New("credentials env var is not set")
+
+// Credentials for accessing private repos and static files.
+// They come from the config's `auth` section, and the user's netrc file.
+type credentials struct {
+	hosts []config.HostAuth
+	netrc []netrcEntry
+}
+
+// A username and password pair, taken from the config or netrc.
+type hostLogin struct {
+	username string
+	password string
+}
+
+// Loads the credentials declared in the config, and the ones in the user's netrc file.
+// The netrc file is read from $NETRC, or ~/.netrc by default.
+func loadCredentials(devnetConfig config.DevnetConfig) (credentials, error) {
+	for _, auth := range devnetConfig.Auth {
+		if auth.Host == "" {
+			return credentials{}, errors.New("auth entries must specify a host")
+		}
+	}
+	netrcPath := os.Getenv("NETRC")
+	if netrcPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return credentials{hosts: devnetConfig.Auth}, nil //nolint:nilerr // netrc is optional
+		}
+		netrcPath = filepath.Join(homeDir, ".netrc")
+	}
+	netrc, err := loadNetrc(netrcPath)
+	if err != nil {
+		return credentials{}, err
+	}
+	return credentials{hosts: devnetConfig.Auth, netrc: netrc}, nil
+}
+
+// Returns the auth config for the host, or nil if there's none.
+func (c credentials) hostAuth(host string) *config.HostAuth {
+	for i, auth := range c.hosts {
+		if strings.EqualFold(auth.Host, host) {
+			return &c.hosts[i]
+		}
+	}
+	return nil
+}
+
+// Checks if there are any credentials specifically for the host.
+// The netrc's default entry isn't considered, since it applies to every host.
+func (c credentials) hasCredentials(host string) bool {
+	if c.hostAuth(host) != nil {
+		return true
+	}
+	for _, entry := range c.netrc {
+		if entry.machine != "" && strings.EqualFold(entry.machine, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the login used for the host, preferring the config's token over netrc.
+// Returns false if there's no login for the host.
+func (c credentials) login(host string) (hostLogin, bool, error) {
+	auth := c.hostAuth(host)
+	if auth != nil && auth.TokenEnv != "" {
+		token, err := readTokenEnv(auth.TokenEnv)
+		if err != nil {
+			return hostLogin{}, false, err
+		}
+		return hostLogin{username: auth.GetUsername(), password: token}, true, nil
+	}
+	entry, ok := lookupNetrc(c.netrc, host)
+	if !ok {
+		return hostLogin{}, false, nil
+	}
+	return hostLogin{username: entry.login, password: entry.password}, true, nil
+}
+
+// Adds the credentials for the request's host to it.
+// Config tokens are sent as bearer tokens, and netrc logins via basic auth.
+// Returns the names of the headers that were set, which shouldn't be sent to other hosts.
+func (c credentials) authorizeRequest(req *http.Request) ([]string, error) {
+	host := req.URL.Hostname()
+	headerNames := make([]string, 0)
+	auth := c.hostAuth(host)
+	if auth != nil && auth.TokenEnv != "" {
+		token, err := readTokenEnv(auth.TokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		headerNames = append(headerNames, "Authorization")
+	} else if entry, ok := lookupNetrc(c.netrc, host); ok {
+		req.SetBasicAuth(entry.login, entry.password)
+		headerNames = append(headerNames, "Authorization")
+	}
+	if auth == nil {
+		return headerNames, nil
+	}
+	for name, value := range auth.Headers {
+		expanded, err := expandHeaderValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("header '%s' for host '%s': %w", name, host, err)
+		}
+		req.Header.Set(name, expanded)
+		headerNames = append(headerNames, name)
+	}
+	return headerNames, nil
+}
+
+// Returns the contents of a git credentials file with the login for the host, if any.
+// The file is in the format expected by git's "store" credential helper.
+func (c credentials) gitCredentialsFile(host string) (string, error) {
+	login, ok, err := c.login(host)
+	if err != nil || !ok {
+		return "", err
+	}
+	credentialsUrl := url.URL{
+		Scheme: "https",
+		User:   url.UserPassword(login.username, login.password),
+		Host:   host,
+	}
+	return credentialsUrl.String() + "\n", nil
+}
+
+// Returns the value of the env var holding a token.
+func readTokenEnv(envVar string) (string, error) {
+	token := os.Getenv(envVar)
+	if token == "" {
+		return "", fmt.Errorf("%w: %s", ErrMissingCredentials, envVar)
+	}
+	return token, nil
+}
+
+// Expands the env vars referenced in a header value, failing if any of them isn't set.
+func expandHeaderValue(value string) (string, error) {
+	var missing []string
+	expanded := os.Expand(value, func(envVar string) string {
+		envValue := os.Getenv(envVar)
+		if envValue == "" {
+			missing = append(missing, envVar)
+		}
+		return envValue
+	})
+	if len(missing) != 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingCredentials, strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// A single entry of a netrc file.
+type netrcEntry struct {
+	// Empty for the default entry
+	machine  string
+	login    string
+	password string
+}
+
+// Parses the netrc file at the given path. Returns no entries if it doesn't exist.
+// Macro definitions are not supported.
+func loadNetrc(path string) ([]netrcEntry, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read netrc file: %w", err)
+	}
+	entries := make([]netrcEntry, 0)
+	tokens := strings.Fields(string(contents))
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				i += 1
+				entries = append(entries, netrcEntry{machine: tokens[i]})
+			}
+		case "default":
+			entries = append(entries, netrcEntry{})
+		case "login", "password":
+			if len(entries) == 0 || i+1 >= len(tokens) {
+				continue
+			}
+			if tokens[i] == "login" {
+				entries[len(entries)-1].login = tokens[i+1]
+			} else {
+				entries[len(entries)-1].password = tokens[i+1]
+			}
+			i += 1
+		}
+	}
+	return entries, nil
+}
+
+// Returns the netrc entry for the host, falling back to the default entry.
+func lookupNetrc(entries []netrcEntry, host string) (netrcEntry, bool) {
+	var defaultEntry *netrcEntry
+	for i, entry := range entries {
+		if entry.machine == "" {
+			defaultEntry = &entries[i]
+			continue
+		}
+		if strings.EqualFold(entry.machine, host) {
+			return entry, true
+		}
+	}
+	if defaultEntry != nil {
+		return *defaultEntry, true
+	}
+	return netrcEntry{}, false
+}
+
+// Matches scp-like git URLs, like `git@github.com:org/repo.git`.
+//
+//nolint:gochecknoglobals // this is a constant
+var scpLikeUrlRegex = regexp.MustCompile(`^[\w.-]+@([\w.-]+):`)
+
+// Checks if the repo is cloned over SSH.
+func isSshRepoUrl(repo string) bool {
+	return strings.HasPrefix(repo, "ssh://") || scpLikeUrlRegex.MatchString(repo)
+}
+
+// Returns the host of a remote repo's URL, including scp-like SSH URLs.
+func repoHost(repo string) string {
+	if match := scpLikeUrlRegex.FindStringSubmatch(repo); match != nil {
+		return match[1]
+	}
+	repoUrl, err := url.Parse(repo)
+	if err != nil {
+		return ""
+	}
+	return repoUrl.Hostname()
+}
+
+// Returns the URL with any credentials in it removed, so it can be shown to the user.
+func redactUrl(rawUrl string) string {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || parsedUrl.User == nil {
+		return rawUrl
+	}
+	// Tokens are sometimes passed as the username, so we remove it too
+	parsedUrl.User = nil
+	return parsedUrl.String()
+}
@@ -0,0 +1,287 @@
+package cmds
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+)
+
+// A line of output from an image build.
+type buildOutputLine struct {
+	jobName string
+	line    string
+}
+
+// A single build, shared by all the services with the same build definition.
+type buildJob struct {
+	// Names of the images built by this job
+	imageNames []string
+	// The first service with this build definition
+	service config.Service
+}
+
+// Returns a name for the job, used to prefix its output.
+func (j buildJob) name() string {
+	return strings.Join(j.imageNames, ",")
+}
+
+// Number of output lines of a failed build to include in the error.
+const failedBuildOutputLines = 30
+
+// Groups the services that need to be built by their build definition.
+// Fails if the same image has multiple build definitions.
+func collectBuildJobs(baseDir string, services []config.Service) ([]buildJob, error) {
+	jobs := make([]buildJob, 0, len(services))
+	jobIndexByKey := make(map[string]int)
+	keyByImage := make(map[string]string)
+	for _, service := range services {
+		var key string
+		switch {
+		case service.BuildContext != nil:
+			buildFile := ""
+			if service.BuildFile != nil {
+				buildFile = *service.BuildFile
+			}
+			key = "context\x00" + ensureAbs(baseDir, *service.BuildContext) + "\x00" + buildFile
+		case service.BuildCmd != nil:
+			key = "cmd\x00" + *service.BuildCmd
+		default:
+			continue
+		}
+		if previousKey, ok := keyByImage[service.Image]; ok {
+			if previousKey != key {
+				return nil, fmt.Errorf("image '%s' has multiple different build definitions", service.Image)
+			}
+			// Image is already being built
+			continue
+		}
+		keyByImage[service.Image] = key
+		if i, ok := jobIndexByKey[key]; ok {
+			jobs[i].imageNames = append(jobs[i].imageNames, service.Image)
+			continue
+		}
+		jobIndexByKey[key] = len(jobs)
+		jobs = append(jobs, buildJob{imageNames: []string{service.Image}, service: service})
+	}
+	return jobs, nil
+}
+
+// Returns the preparation tasks that build the local docker images for the services in the configuration.
+// Each image is built only once, even if used by multiple services.
+// Images whose inputs didn't change since their last build are skipped, unless `rebuild` is set.
+func buildTasks(baseDir string, config config.DevnetConfig, rebuild bool) ([]prepTask, error) {
+	jobs, err := collectBuildJobs(baseDir, config.Services)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]prepTask, 0, len(jobs))
+	for _, job := range jobs {
+		tasks = append(tasks, prepTask{
+			id:   "build:" + job.name(),
+			kind: prepTaskBuild,
+			run: func(ctx context.Context, outputChan chan<- buildOutputLine) (string, error) {
+				cached, err := runBuildJob(ctx, outputChan, baseDir, job, rebuild)
+				if err != nil {
+					return "", err
+				}
+				if cached {
+					return fmt.Sprintf("'%s' is up to date", job.name()), nil
+				}
+				return fmt.Sprintf("Built '%s'", job.name()), nil
+			},
+		})
+	}
+	return tasks, nil
+}
+
+// Runs a single build job, with docker or the custom command, depending on the build definition.
+// Returns true if the build was skipped.
+func runBuildJob(
+	ctx context.Context,
+	outputChan chan<- buildOutputLine,
+	baseDir string,
+	job buildJob,
+	rebuild bool,
+) (bool, error) {
+	service := job.service
+	if service.BuildContext != nil {
+		buildContext := ensureAbs(baseDir, *service.BuildContext)
+		return buildWithDocker(ctx, outputChan, job, buildContext, service.BuildFile, rebuild)
+	}
+	return buildWithCustomCmd(ctx, outputChan, job, baseDir, rebuild)
+}
+
+// Builds the job's docker images from the given build context and (optional) file.
+// The build is skipped if the images were built from the same inputs, unless `rebuild` is set.
+// Returns true if the build was skipped.
+func buildWithDocker(
+	ctx context.Context,
+	outputChan chan<- buildOutputLine,
+	job buildJob,
+	buildContext string,
+	buildFile *string,
+	rebuild bool,
+) (bool, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return false, &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	cmdArgs, buildHash, err := dockerBuildArgs(job, buildContext, buildFile)
+	if err != nil {
+		return false, err
+	}
+	if !rebuild && allImagesMatch(job.imageNames, func(imageName string) bool {
+		return getImageBuildHash(ctx, imageName) == buildHash
+	}) {
+		return true, nil
+	}
+	cmdArgs = append(cmdArgs, "--label", buildHashLabel+"="+buildHash)
+	cmd := newCmd(ctx, "docker", cmdArgs...)
+	output, err := runStreamingOutput(cmd, outputChan, job.name())
+	if err != nil {
+		return false, fmt.Errorf("building '%s' failed: %w\n%s", job.name(), err, output)
+	}
+	return false, nil
+}
+
+// Returns the args of the job's `docker build` command, and the hash of the build's inputs.
+func dockerBuildArgs(job buildJob, buildContext string, buildFile *string) ([]string, string, error) {
+	cmdArgs := []string{"build", buildContext}
+	for _, imageName := range job.imageNames {
+		cmdArgs = append(cmdArgs, "-t", imageName)
+	}
+	buildFilePath := filepath.Join(buildContext, "Dockerfile")
+	if buildFile != nil {
+		cmdArgs = append(cmdArgs, "-f", *buildFile)
+		buildFilePath = *buildFile
+	}
+	buildHash, err := hashDockerBuild(buildContext, buildFilePath, cmdArgs)
+	if err != nil {
+		return nil, "", fmt.Errorf("hashing build inputs of '%s' failed: %w", job.name(), err)
+	}
+	return cmdArgs, buildHash, nil
+}
+
+// Returns the job's images that exist locally, but were built from different inputs.
+// These are rebuilt on the next start.
+// Custom builds without declared inputs are always rebuilt, so their images are never outdated.
+func outdatedImages(ctx context.Context, baseDir string, job buildJob) ([]string, error) {
+	service := job.service
+	var isUpToDate func(imageName string) bool
+	switch {
+	case service.BuildContext != nil:
+		buildContext := ensureAbs(baseDir, *service.BuildContext)
+		_, buildHash, err := dockerBuildArgs(job, buildContext, service.BuildFile)
+		if err != nil {
+			return nil, err
+		}
+		isUpToDate = func(imageName string) bool {
+			return getImageBuildHash(ctx, imageName) == buildHash
+		}
+	case len(service.BuildInputs) != 0:
+		buildHash, err := hashCustomBuild(baseDir, *service.BuildCmd, service.BuildInputs)
+		if err != nil {
+			return nil, fmt.Errorf("hashing build inputs of '%s' failed: %w", job.name(), err)
+		}
+		isUpToDate = func(imageName string) bool {
+			return isCustomBuildCached(ctx, imageName, buildHash)
+		}
+	default:
+		return nil, nil
+	}
+	outdated := make([]string, 0)
+	for _, imageName := range job.imageNames {
+		if getImageId(ctx, imageName) != "" && !isUpToDate(imageName) {
+			outdated = append(outdated, imageName)
+		}
+	}
+	return outdated, nil
+}
+
+// Checks if `matches` returns true for all the images.
+func allImagesMatch(imageNames []string, matches func(imageName string) bool) bool {
+	for _, imageName := range imageNames {
+		if !matches(imageName) {
+			return false
+		}
+	}
+	return true
+}
+
+// Builds the job's docker images with a custom command.
+// The command is executed inside a shell.
+// If the service declares its build inputs, the build is skipped when they didn't change,
+// unless `rebuild` is set. Returns true if the build was skipped.
+func buildWithCustomCmd(
+	ctx context.Context,
+	outputChan chan<- buildOutputLine,
+	job buildJob,
+	baseDir string,
+	rebuild bool,
+) (bool, error) {
+	buildCmd := *job.service.BuildCmd
+	buildInputs := job.service.BuildInputs
+	var buildHash string
+	if len(buildInputs) != 0 {
+		var err error
+		buildHash, err = hashCustomBuild(baseDir, buildCmd, buildInputs)
+		if err != nil {
+			return false, fmt.Errorf("hashing build inputs of '%s' failed: %w", job.name(), err)
+		}
+		if !rebuild && allImagesMatch(job.imageNames, func(imageName string) bool {
+			return isCustomBuildCached(ctx, imageName, buildHash)
+		}) {
+			return true, nil
+		}
+	}
+	cmd := executeCmdInsideDir(ctx, baseDir, buildCmd)
+	output, err := runStreamingOutput(cmd, outputChan, job.name())
+	if err != nil {
+		return false, fmt.Errorf("building '%s' failed: %w\n%s", job.name(), err, output)
+	}
+	if buildHash == "" {
+		return false, nil
+	}
+	for _, imageName := range job.imageNames {
+		err = storeCustomBuildHash(ctx, imageName, buildHash)
+		if err != nil {
+			return false, fmt.Errorf("storing build hash of image '%s' failed: %w", imageName, err)
+		}
+	}
+	return false, nil
+}
+
+// Runs the command, sending each line of its combined output to `outputChan`.
+// Returns the last lines of the output, prefixed with the job's name.
+func runStreamingOutput(cmd *exec.Cmd, outputChan chan<- buildOutputLine, jobName string) (string, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	cmd.Stdout = pipeWriter
+	cmd.Stderr = pipeWriter
+
+	lastLines := make([]string, 0, failedBuildOutputLines)
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		scanner := bufio.NewScanner(pipeReader)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if len(lastLines) == failedBuildOutputLines {
+				lastLines = lastLines[1:]
+			}
+			lastLines = append(lastLines, "["+jobName+"] "+line)
+			outputChan <- buildOutputLine{jobName, line}
+		}
+		// Drain any remaining output (e.g. after a line was too long)
+		_, _ = io.Copy(io.Discard, pipeReader)
+	}()
+	err := cmd.Run()
+	_ = pipeWriter.Close()
+	<-scanDone
+	return strings.Join(lastLines, "\n"), err
+}
@@ -0,0 +1,288 @@
+package cmds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// Label used to store the hash of an image's build inputs.
+const buildHashLabel = "com.layr-labs.avs-devnet.build-hash"
+
+// Bumped whenever the hashing scheme changes, to invalidate old hashes.
+const buildHashVersion = "v2"
+
+// Returns a hash of a docker build's inputs.
+// These are the files inside the build context (respecting .dockerignore), the build file, and the build args.
+func hashDockerBuild(buildContext string, buildFile string, buildArgs []string) (string, error) {
+	matcher, err := loadDockerignore(buildContext, buildFile)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	writeHashField(h, buildHashVersion)
+	for _, arg := range buildArgs {
+		writeHashField(h, arg)
+	}
+	err = hashFile(h, buildFile)
+	if err != nil {
+		return "", err
+	}
+	err = hashDir(h, buildContext, func(relPath string, isDir bool) bool {
+		excluded, _ := matcher.MatchesOrParentMatches(relPath)
+		if !excluded {
+			return true
+		}
+		// Excluded dirs need to be walked only if some of their files may be re-included
+		return isDir && matcher.Exclusions()
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Returns a hash of a custom build's inputs.
+// These are the build command, and the files inside `baseDir` that match any of the given glob patterns.
+func hashCustomBuild(baseDir string, buildCmd string, inputGlobs []string) (string, error) {
+	h := sha256.New()
+	writeHashField(h, buildHashVersion)
+	writeHashField(h, buildCmd)
+	if len(inputGlobs) == 0 {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	matcher, err := patternmatcher.New(inputGlobs)
+	if err != nil {
+		return "", fmt.Errorf("invalid build inputs: %w", err)
+	}
+	// Only the dirs that may contain matches are walked, instead of the whole base dir
+	for _, root := range buildInputRoots(inputGlobs) {
+		err = hashBuildInputRoot(h, baseDir, root, matcher)
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Returns the slash-separated paths, relative to the base dir, that contain all the files matching the patterns.
+// These are the static prefixes of glob patterns, and the paths of the other patterns.
+// Paths inside other returned paths are omitted, so each file is hashed once.
+func buildInputRoots(inputGlobs []string) []string {
+	roots := make([]string, 0, len(inputGlobs))
+	for _, pattern := range inputGlobs {
+		if isGlobPattern(pattern) {
+			pattern, _ = splitGlobPattern(pattern)
+		}
+		roots = append(roots, path.Clean(filepath.ToSlash(pattern)))
+	}
+	slices.Sort(roots)
+	var result []string
+	for _, root := range roots {
+		if len(result) == 0 || !isSubpath(result[len(result)-1], root) {
+			result = append(result, root)
+		}
+	}
+	return result
+}
+
+// Checks if the slash-separated path is the same as the parent, or inside it.
+func isSubpath(parent string, relPath string) bool {
+	return parent == "." || relPath == parent || strings.HasPrefix(relPath, parent+"/")
+}
+
+// Hashes the files matching the build inputs inside `root`, a slash-separated path relative to `baseDir`.
+// Missing roots are skipped, since their patterns just don't match anything.
+func hashBuildInputRoot(h hash.Hash, baseDir string, root string, matcher *patternmatcher.PatternMatcher) error {
+	rootPath := filepath.Join(baseDir, filepath.FromSlash(root))
+	info, err := os.Stat(rootPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		// A glob's static prefix may name a file, which the glob doesn't match
+		if matches, _ := matcher.MatchesOrParentMatches(root); !matches {
+			return nil
+		}
+		writeHashField(h, root)
+		writeHashField(h, info.Mode().String())
+		return hashFile(h, rootPath)
+	}
+	writeHashField(h, root)
+	return hashDir(h, rootPath, func(relPath string, isDir bool) bool {
+		relPath = path.Join(root, relPath)
+		if isDir {
+			// We can't know if a pattern matches files inside the dir, so we walk it
+			return relPath != ".git"
+		}
+		matches, _ := matcher.MatchesOrParentMatches(relPath)
+		return matches
+	})
+}
+
+// Returns the pattern matcher for the .dockerignore file used in the build.
+// Like docker, a `<build file>.dockerignore` file takes precedence over the one in the build context.
+func loadDockerignore(buildContext string, buildFile string) (*patternmatcher.PatternMatcher, error) {
+	ignoreFilePath := buildFile + ".dockerignore"
+	if !fileExists(ignoreFilePath) {
+		ignoreFilePath = filepath.Join(buildContext, ".dockerignore")
+	}
+	var patterns []string
+	file, err := os.Open(ignoreFilePath)
+	if err == nil {
+		defer file.Close()
+		patterns, err = ignorefile.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", ignoreFilePath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open '%s': %w", ignoreFilePath, err)
+	}
+	return patternmatcher.New(patterns)
+}
+
+// Hashes the files inside `root` in a deterministic order.
+// `include` is called with each entry's slash-separated path relative to `root`.
+// Files for which it returns false are skipped, and so are the contents of dirs.
+func hashDir(h hash.Hash, root string, include func(relPath string, isDir bool) bool) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !include(relPath, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		writeHashField(h, relPath)
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// Hash the link itself instead of its target
+			var target string
+			target, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			writeHashField(h, "symlink:"+target)
+			return nil
+		}
+		var info fs.FileInfo
+		info, err = entry.Info()
+		if err != nil {
+			return err
+		}
+		writeHashField(h, info.Mode().String())
+		return hashFile(h, path)
+	})
+}
+
+// Writes the contents of the file to the hash.
+func hashFile(h hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	// Separate the contents from the next field
+	writeHashField(h, "")
+	return nil
+}
+
+// Writes a NUL-terminated field to the hash.
+func writeHashField(h hash.Hash, field string) {
+	_, _ = h.Write([]byte(field))
+	_, _ = h.Write([]byte{0})
+}
+
+// Returns the build hash stored in the image's labels.
+// Returns an empty string if the image doesn't exist or has no hash.
+func getImageBuildHash(ctx context.Context, imageName string) string {
+	format := fmt.Sprintf(`{{ index .Config.Labels "%s" }}`, buildHashLabel)
+	output, err := newCmd(ctx, "docker", "image", "inspect", "--format", format, imageName).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Returns the ID of the image, or an empty string if it doesn't exist.
+func getImageId(ctx context.Context, imageName string) string {
+	output, err := newCmd(ctx, "docker", "image", "inspect", "--format", "{{ .Id }}", imageName).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Returns the path of the file storing the build hash of a custom-built image.
+// Since custom builds can't be labeled, we store their hash along with the image's ID.
+func customBuildCachePath(imageName string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	fileName := hex.EncodeToString([]byte(imageName))
+	return filepath.Join(cacheDir, "avs-devnet", "builds", fileName), nil
+}
+
+// Checks if the custom-built image was built with the given hash, and wasn't modified since.
+func isCustomBuildCached(ctx context.Context, imageName string, buildHash string) bool {
+	cachePath, err := customBuildCachePath(imageName)
+	if err != nil {
+		return false
+	}
+	contents, err := os.ReadFile(cachePath)
+	if err != nil {
+		return false
+	}
+	imageId := getImageId(ctx, imageName)
+	return imageId != "" && string(contents) == buildHash+" "+imageId
+}
+
+// Stores the hash of the custom-built image, along with its ID.
+func storeCustomBuildHash(ctx context.Context, imageName string, buildHash string) error {
+	cachePath, err := customBuildCachePath(imageName)
+	if err != nil {
+		return err
+	}
+	imageId := getImageId(ctx, imageName)
+	if imageId == "" {
+		return fmt.Errorf("image '%s' not found after build", imageName)
+	}
+	err = os.MkdirAll(filepath.Dir(cachePath), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, []byte(buildHash+" "+imageId), 0600)
+}
@@ -0,0 +1,363 @@
+package cmds_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes the files inside the dir, by slash-separated path.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for relPath, contents := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(relPath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		require.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+	}
+}
+
+// Files of the docker build contexts used in the tests.
+func buildContextFiles() map[string]string {
+	return map[string]string{
+		"Dockerfile":        "FROM alpine\nCOPY . /app\n",
+		"main.go":           "package main",
+		"pkg/lib.go":        "package pkg",
+		"app.log":           "logs",
+		"ignored/other.txt": "other",
+		"ignored/keep.txt":  "keep",
+		".dockerignore":     "*.log\nignored/\n!ignored/keep.txt\n",
+	}
+}
+
+func TestHashDockerBuild(t *testing.T) {
+	t.Parallel()
+	buildArgs := []string{"VERSION=1"}
+	tests := []struct {
+		name string
+		// Changes the build context, and returns the build args to use
+		change  func(t *testing.T, dir string) []string
+		changed bool
+	}{
+		{
+			name:   "no changes",
+			change: func(*testing.T, string) []string { return buildArgs },
+		},
+		{
+			name: "included file",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"pkg/lib.go": "package pkg // changed"})
+				return buildArgs
+			},
+			changed: true,
+		},
+		{
+			name: "new file",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"pkg/new.go": "package pkg"})
+				return buildArgs
+			},
+			changed: true,
+		},
+		{
+			name: "removed file",
+			change: func(t *testing.T, dir string) []string {
+				require.NoError(t, os.Remove(filepath.Join(dir, "main.go")))
+				return buildArgs
+			},
+			changed: true,
+		},
+		{
+			name: "file mode",
+			change: func(t *testing.T, dir string) []string {
+				require.NoError(t, os.Chmod(filepath.Join(dir, "main.go"), 0700))
+				return buildArgs
+			},
+			changed: true,
+		},
+		{
+			name: "build file",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"Dockerfile": "FROM debian\n"})
+				return buildArgs
+			},
+			changed: true,
+		},
+		{
+			name:    "build arg",
+			change:  func(*testing.T, string) []string { return []string{"VERSION=2"} },
+			changed: true,
+		},
+		{
+			name:    "new build arg",
+			change:  func(*testing.T, string) []string { return append([]string{"DEBUG=1"}, buildArgs...) },
+			changed: true,
+		},
+		{
+			name: "ignored file",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"app.log": "more logs", "debug.log": "debug"})
+				return buildArgs
+			},
+		},
+		{
+			name: "file in ignored dir",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"ignored/other.txt": "changed", "ignored/new.txt": "new"})
+				return buildArgs
+			},
+		},
+		{
+			name: "re-included file",
+			change: func(t *testing.T, dir string) []string {
+				writeTestFiles(t, dir, map[string]string{"ignored/keep.txt": "changed"})
+				return buildArgs
+			},
+			changed: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, buildContextFiles())
+			buildFile := filepath.Join(dir, "Dockerfile")
+			hash, err := cmds.HashDockerBuild(dir, buildFile, buildArgs)
+			require.NoError(t, err)
+
+			newArgs := tc.change(t, dir)
+			newHash, err := cmds.HashDockerBuild(dir, buildFile, newArgs)
+			require.NoError(t, err)
+			if tc.changed {
+				require.NotEqual(t, hash, newHash)
+			} else {
+				require.Equal(t, hash, newHash)
+			}
+		})
+	}
+}
+
+func TestHashDockerBuildIsStable(t *testing.T) {
+	t.Parallel()
+	// The same files in different dirs have the same hash
+	firstDir := t.TempDir()
+	secondDir := t.TempDir()
+	writeTestFiles(t, firstDir, buildContextFiles())
+	writeTestFiles(t, secondDir, buildContextFiles())
+	firstHash, err := cmds.HashDockerBuild(firstDir, filepath.Join(firstDir, "Dockerfile"), nil)
+	require.NoError(t, err)
+	secondHash, err := cmds.HashDockerBuild(secondDir, filepath.Join(secondDir, "Dockerfile"), nil)
+	require.NoError(t, err)
+	require.Equal(t, firstHash, secondHash)
+	// Hex-encoded SHA-256
+	require.Len(t, firstHash, 64)
+}
+
+func TestHashCustomBuild(t *testing.T) {
+	t.Parallel()
+	inputs := []string{"src/**/*.go", "go.mod"}
+	tests := []struct {
+		name     string
+		buildCmd string
+		files    map[string]string
+		changed  bool
+	}{
+		{name: "no changes", buildCmd: "make build"},
+		{name: "build command", buildCmd: "make release", changed: true},
+		{
+			name:     "matched file",
+			buildCmd: "make build",
+			files:    map[string]string{"src/cmd/main.go": "changed"},
+			changed:  true,
+		},
+		{
+			name:     "new matched file",
+			buildCmd: "make build",
+			files:    map[string]string{"src/new.go": "new"},
+			changed:  true,
+		},
+		{
+			name:     "exact input",
+			buildCmd: "make build",
+			files:    map[string]string{"go.mod": "module changed"},
+			changed:  true,
+		},
+		{
+			name:     "unmatched file",
+			buildCmd: "make build",
+			files:    map[string]string{"README.md": "changed", "src/notes.txt": "notes"},
+		},
+		{
+			name:     "git dir",
+			buildCmd: "make build",
+			files:    map[string]string{".git/src/main.go": "changed"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{
+				"src/cmd/main.go": "package main",
+				"go.mod":          "module example",
+				"README.md":       "docs",
+			})
+			hash, err := cmds.HashCustomBuild(dir, "make build", inputs)
+			require.NoError(t, err)
+
+			writeTestFiles(t, dir, tc.files)
+			newHash, err := cmds.HashCustomBuild(dir, tc.buildCmd, inputs)
+			require.NoError(t, err)
+			if tc.changed {
+				require.NotEqual(t, hash, newHash)
+			} else {
+				require.Equal(t, hash, newHash)
+			}
+		})
+	}
+}
+
+func TestHashCustomBuildWithoutInputs(t *testing.T) {
+	t.Parallel()
+	// The base dir isn't read when there are no inputs
+	missingDir := filepath.Join(t.TempDir(), "missing")
+	hash, err := cmds.HashCustomBuild(missingDir, "make build", nil)
+	require.NoError(t, err)
+	otherHash, err := cmds.HashCustomBuild(missingDir, "make release", nil)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+}
+
+func TestHashCustomBuildInputRoots(t *testing.T) {
+	t.Parallel()
+	// Nested, file and missing inputs
+	inputs := []string{"contracts/**/*.sol", "contracts/src/*.sol", "contracts/*.toml", "deploy.sh", "missing/*"}
+	tests := []struct {
+		name    string
+		files   map[string]string
+		changed bool
+	}{
+		{name: "nested input", files: map[string]string{"contracts/src/Token.sol": "changed"}, changed: true},
+		{name: "file input", files: map[string]string{"deploy.sh": "forge script --broadcast"}, changed: true},
+		{name: "unmatched sibling", files: map[string]string{"contracts/src/notes.txt": "notes"}},
+		{name: "outside inputs", files: map[string]string{"docs/README.md": "docs"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{
+				"contracts/src/Token.sol": "contract Token {}",
+				"contracts/foundry.toml":  "[profile.default]",
+				"deploy.sh":               "forge script",
+			})
+			hash, err := cmds.HashCustomBuild(dir, "make build", inputs)
+			require.NoError(t, err)
+
+			writeTestFiles(t, dir, tc.files)
+			newHash, err := cmds.HashCustomBuild(dir, "make build", inputs)
+			require.NoError(t, err)
+			if tc.changed {
+				require.NotEqual(t, hash, newHash)
+			} else {
+				require.Equal(t, hash, newHash)
+			}
+		})
+	}
+}
+
+func TestHashCustomBuildInvalidInputs(t *testing.T) {
+	t.Parallel()
+	_, err := cmds.HashCustomBuild(t.TempDir(), "make", []string{"[invalid"})
+	require.ErrorContains(t, err, "invalid build inputs")
+}
+
+func TestLoadDockerignore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		// Whether each path is excluded from the build context
+		excluded map[string]bool
+	}{
+		{
+			name:     "no ignore file",
+			files:    map[string]string{},
+			excluded: map[string]bool{"app.log": false, "node_modules/lib.js": false},
+		},
+		{
+			name:  "context ignore file",
+			files: map[string]string{".dockerignore": "# comment\n*.log\nnode_modules\n"},
+			excluded: map[string]bool{
+				"app.log":             true,
+				"node_modules/lib.js": true,
+				"src/app.log":         false,
+				"main.go":             false,
+			},
+		},
+		{
+			name:  "exceptions",
+			files: map[string]string{".dockerignore": "docs/\n!docs/README.md\n"},
+			excluded: map[string]bool{
+				"docs/guide.md":  true,
+				"docs/README.md": false,
+			},
+		},
+		{
+			name: "build file ignore file takes precedence",
+			files: map[string]string{
+				".dockerignore":           "*.log\n",
+				"Dockerfile.dockerignore": "*.md\n",
+			},
+			excluded: map[string]bool{"app.log": false, "README.md": true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tc.files)
+			matcher, err := cmds.LoadDockerignore(dir, filepath.Join(dir, "Dockerfile"))
+			require.NoError(t, err)
+			for path, expected := range tc.excluded {
+				excluded, err := matcher.MatchesOrParentMatches(path)
+				require.NoError(t, err)
+				require.Equal(t, expected, excluded, "path '%s'", path)
+			}
+		})
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	t.Parallel()
+	hashDir := func(t *testing.T, dir string) string {
+		t.Helper()
+		h := sha256.New()
+		require.NoError(t, cmds.HashDir(h, dir, func(string, bool) bool { return true }))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.txt": "a", "dir/b.txt": "b", "target.txt": "target"})
+	require.NoError(t, os.Symlink("target.txt", filepath.Join(dir, "link")))
+	hash := hashDir(t, dir)
+	require.Equal(t, hash, hashDir(t, dir))
+
+	// Links are hashed by their target, instead of the target's contents
+	writeTestFiles(t, dir, map[string]string{"target.txt": "changed"})
+	changedTargetHash := hashDir(t, dir)
+	require.NotEqual(t, hash, changedTargetHash)
+	require.NoError(t, os.Remove(filepath.Join(dir, "link")))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(dir, "link")))
+	require.NotEqual(t, changedTargetHash, hashDir(t, dir))
+
+	// Moving a file changes the hash, even with the same contents
+	movedDir := t.TempDir()
+	writeTestFiles(t, movedDir, map[string]string{"a.txt": "a", "b.txt": "b"})
+	otherDir := t.TempDir()
+	writeTestFiles(t, otherDir, map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	require.NotEqual(t, hashDir(t, movedDir), hashDir(t, otherDir))
+}
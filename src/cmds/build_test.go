@@ -0,0 +1,115 @@
+package cmds_test
+
+import (
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBuildJobs(t *testing.T) {
+	t.Parallel()
+	baseDir := "/devnet"
+	contextBuild := func(image string, buildContext string) config.Service {
+		return config.Service{Name: image, Image: image, BuildContext: &buildContext}
+	}
+	fileBuild := func(image string, buildContext string, buildFile string) config.Service {
+		service := contextBuild(image, buildContext)
+		service.BuildFile = &buildFile
+		return service
+	}
+	cmdBuild := func(image string, buildCmd string) config.Service {
+		return config.Service{Name: image, Image: image, BuildCmd: &buildCmd}
+	}
+	tests := []struct {
+		name     string
+		services []config.Service
+		expected [][]string
+		err      string
+	}{
+		{
+			name:     "no builds",
+			services: []config.Service{{Name: "anvil", Image: "ghcr.io/foundry-rs/foundry"}},
+			expected: [][]string{},
+		},
+		{
+			name:     "same image and context",
+			services: []config.Service{contextBuild("app", "./app"), contextBuild("app", "./app")},
+			expected: [][]string{{"app"}},
+		},
+		{
+			name:     "same context with relative and absolute paths",
+			services: []config.Service{contextBuild("app", "./app"), contextBuild("app", "/devnet/app")},
+			expected: [][]string{{"app"}},
+		},
+		{
+			name:     "shared context with different images",
+			services: []config.Service{contextBuild("operator", "."), contextBuild("aggregator", ".")},
+			expected: [][]string{{"operator", "aggregator"}},
+		},
+		{
+			name:     "distinct contexts",
+			services: []config.Service{contextBuild("operator", "./operator"), contextBuild("aggregator", "./aggregator")},
+			expected: [][]string{{"operator"}, {"aggregator"}},
+		},
+		{
+			name: "distinct build files",
+			services: []config.Service{
+				fileBuild("operator", ".", "operator.Dockerfile"),
+				fileBuild("aggregator", ".", "aggregator.Dockerfile"),
+			},
+			expected: [][]string{{"operator"}, {"aggregator"}},
+		},
+		{
+			name:     "shared build command",
+			services: []config.Service{cmdBuild("operator", "make images"), cmdBuild("aggregator", "make images")},
+			expected: [][]string{{"operator", "aggregator"}},
+		},
+		{
+			name:     "distinct build commands",
+			services: []config.Service{cmdBuild("operator", "make operator"), cmdBuild("aggregator", "make aggregator")},
+			expected: [][]string{{"operator"}, {"aggregator"}},
+		},
+		{
+			name: "build context takes precedence over build command",
+			services: []config.Service{
+				{Name: "app", Image: "app", BuildContext: ptr("./app"), BuildCmd: ptr("make app")},
+				contextBuild("app", "./app"),
+			},
+			expected: [][]string{{"app"}},
+		},
+		{
+			name:     "conflicting contexts",
+			services: []config.Service{contextBuild("app", "./app"), contextBuild("app", "./other")},
+			err:      "image 'app' has multiple different build definitions",
+		},
+		{
+			name:     "conflicting build files",
+			services: []config.Service{contextBuild("app", "."), fileBuild("app", ".", "other.Dockerfile")},
+			err:      "image 'app' has multiple different build definitions",
+		},
+		{
+			name:     "conflicting build kinds",
+			services: []config.Service{contextBuild("app", "./app"), cmdBuild("app", "make app")},
+			err:      "image 'app' has multiple different build definitions",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			jobs, err := cmds.CollectBuildJobs(baseDir, tc.services)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, jobs)
+		})
+	}
+}
+
+// Returns a pointer to the value.
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,398 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
+	"github.com/kurtosis-tech/kurtosis/api/golang/engine/lib/kurtosis_context"
+	"github.com/urfave/cli/v2"
+)
+
+// Status of a doctor check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// The result of checking a single part of the environment.
+type CheckResult struct {
+	Name    string      `json:"name"`
+	Status  CheckStatus `json:"status"`
+	Message string      `json:"message"`
+	// Optional. How to fix the problem found
+	Fix string `json:"fix,omitempty"`
+}
+
+// The results of all the doctor checks, along with info about the host.
+type DoctorReport struct {
+	Version            string        `json:"version"`
+	OS                 string        `json:"os"`
+	Arch               string        `json:"arch"`
+	KurtosisSdkVersion string        `json:"kurtosis_sdk_version"`
+	Checks             []CheckResult `json:"checks"`
+}
+
+// Thresholds for the Docker resources checks.
+const (
+	gib             = 1 << 30
+	minDockerMemory = 4 * gib
+	recDockerMemory = 8 * gib
+	recDockerCpus   = 4
+	minFreeDisk     = 5 * gib
+	recFreeDisk     = 20 * gib
+)
+
+// Chain types supported by the Kurtosis package.
+//
+//nolint:gochecknoglobals // this is a constant
+var chainTypes = []string{config.ChainTypeEthereumPackage, config.ChainTypeAnvil}
+
+// Host ports published by the Kurtosis engine.
+//
+//nolint:gochecknoglobals // this is a constant
+var enginePorts = []uint16{kurtosis_context.DefaultGrpcEngineServerPortNum, 9711, 9779}
+
+// Checks the environment for common problems, printing the results.
+// Fails if any of the checks failed.
+func DoctorCmd(ctx *cli.Context) error {
+	configPath := ctx.Args().First()
+	if ctx.Args().Len() > 1 {
+		return cli.Exit("expected none or 1 argument: [<file-name>]", 1)
+	}
+	if configPath == "" && fileExists("devnet.yaml") {
+		configPath = "devnet.yaml"
+	}
+	report := DoctorReport{
+		Version:            ctx.App.Version,
+		OS:                 runtime.GOOS,
+		Arch:               runtime.GOARCH,
+		KurtosisSdkVersion: kurtosis.SdkVersion,
+		Checks:             RunDoctor(ctx.Context, configPath),
+	}
+	if flags.JsonFlag.Get(ctx) {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+		fmt.Println(string(output))
+	} else {
+		printDoctorReport(report)
+	}
+	for _, check := range report.Checks {
+		if check.Status == CheckFail {
+			return cli.Exit("", ExitCodeFailure)
+		}
+	}
+	return nil
+}
+
+// Runs all the doctor checks.
+// If `configPath` is not empty, the devnet config at that path is checked too.
+func RunDoctor(ctx context.Context, configPath string) []CheckResult {
+	checks := make([]CheckResult, 0)
+	info, dockerCheck := checkDocker(ctx)
+	checks = append(checks, dockerCheck)
+	if info != nil {
+		checks = append(checks, checkDockerResources(*info)...)
+	}
+	checks = append(checks, checkFreeDisk())
+	engineCheck, engineRunning := checkKurtosisEngine(ctx)
+	checks = append(checks, checkKurtosisCli(ctx), engineCheck)
+	if !engineRunning {
+		checks = append(checks, checkEnginePorts())
+	}
+	checks = append(checks, checkGit(ctx))
+	if info != nil {
+		checks = append(checks, checkForgeImage(ctx))
+	}
+	if configPath != "" {
+		checks = append(checks, checkConfig(ctx, configPath, info != nil)...)
+	}
+	return checks
+}
+
+func printDoctorReport(report DoctorReport) {
+	counts := make(map[CheckStatus]int)
+	for _, check := range report.Checks {
+		counts[check.Status] += 1
+		fmt.Printf("[%s] %s: %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Message)
+		if check.Fix != "" {
+			fmt.Printf("       Fix: %s\n", check.Fix)
+		}
+	}
+	fmt.Printf("\n%d passed, %d warnings, %d failed\n", counts[CheckPass], counts[CheckWarn], counts[CheckFail])
+	if counts[CheckFail]+counts[CheckWarn] != 0 {
+		fmt.Println("When reporting a bug, include the output of `avs-devnet doctor --json`")
+	}
+}
+
+// Fields of `docker info` used by the checks.
+type dockerInfo struct {
+	ServerVersion string
+	NCPU          int
+	MemTotal      int64
+	Architecture  string
+}
+
+// Checks the Docker CLI is installed and the daemon is running.
+// Returns the daemon's info if it's running.
+func checkDocker(ctx context.Context) (*dockerInfo, CheckResult) {
+	check := CheckResult{Name: "Docker"}
+	if _, err := exec.LookPath("docker"); err != nil {
+		check.Status = CheckFail
+		check.Message = "docker CLI not found"
+		check.Fix = "install Docker: " + dependencyInstallUrls["docker"]
+		return nil, check
+	}
+	output, err := newCmd(ctx, "docker", "info", "--format", "{{json .}}").Output()
+	var info dockerInfo
+	if err == nil {
+		err = json.Unmarshal(output, &info)
+	}
+	if err != nil || info.ServerVersion == "" {
+		check.Status = CheckFail
+		check.Message = "Docker daemon is not running"
+		check.Fix = "start Docker Desktop or the Docker daemon (e.g. `sudo systemctl start docker`)"
+		return nil, check
+	}
+	check.Status = CheckPass
+	check.Message = "daemon running, version " + info.ServerVersion
+	return &info, check
+}
+
+// Checks the memory, CPUs and architecture available to Docker.
+func checkDockerResources(info dockerInfo) []CheckResult {
+	memory := CheckResult{
+		Name:    "Docker memory",
+		Status:  CheckPass,
+		Message: fmt.Sprintf("%.1f GiB available", float64(info.MemTotal)/gib),
+	}
+	memoryFix := "increase the memory limit in Docker Desktop's Resources settings"
+	if info.MemTotal < minDockerMemory {
+		memory.Status = CheckFail
+		memory.Message += fmt.Sprintf(", at least %d GiB are needed", minDockerMemory/gib)
+		memory.Fix = memoryFix
+	} else if info.MemTotal < recDockerMemory {
+		memory.Status = CheckWarn
+		memory.Message += fmt.Sprintf(", %d GiB recommended", recDockerMemory/gib)
+		memory.Fix = memoryFix
+	}
+
+	cpus := CheckResult{Name: "Docker CPUs", Status: CheckPass, Message: fmt.Sprintf("%d available", info.NCPU)}
+	if info.NCPU < recDockerCpus {
+		cpus.Status = CheckWarn
+		cpus.Message += fmt.Sprintf(", %d recommended", recDockerCpus)
+		cpus.Fix = "increase the CPU limit in Docker Desktop's Resources settings"
+	}
+
+	arch := CheckResult{Name: "Docker architecture", Status: CheckPass, Message: info.Architecture}
+	if info.Architecture == "aarch64" || info.Architecture == "arm64" {
+		arch.Status = CheckWarn
+		arch.Message += ", some images are amd64-only and run under emulation, which is slower and may crash"
+		arch.Fix = "enable \"Use Rosetta for x86_64/amd64 emulation\" in Docker Desktop's General settings"
+	}
+	return []CheckResult{memory, cpus, arch}
+}
+
+// Checks the free disk space in the current dir.
+// This is where the images are built from, and usually the same disk Docker uses.
+func checkFreeDisk() CheckResult {
+	check := CheckResult{Name: "Free disk"}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(".", &stat); err != nil {
+		check.Status = CheckWarn
+		check.Message = "failed to get free disk space: " + err.Error()
+		check.Fix = "check the free disk space manually, e.g. with `df -h`"
+		return check
+	}
+	//nolint:gosec // block sizes are always positive
+	free := stat.Bavail * uint64(stat.Bsize)
+	check.Status = CheckPass
+	check.Message = fmt.Sprintf("%.1f GiB free", float64(free)/gib)
+	fix := "free up disk space, e.g. by removing unused Docker data with `docker system prune`"
+	if free < minFreeDisk {
+		check.Status = CheckFail
+		check.Message += fmt.Sprintf(", at least %d GiB are needed", minFreeDisk/gib)
+		check.Fix = fix
+	} else if free < recFreeDisk {
+		check.Status = CheckWarn
+		check.Message += fmt.Sprintf(", %d GiB recommended", recFreeDisk/gib)
+		check.Fix = fix
+	}
+	return check
+}
+
+// Reports the Kurtosis CLI's version. It's optional, since the engine is managed through Docker,
+// but it's handy to inspect enclaves.
+func checkKurtosisCli(ctx context.Context) CheckResult {
+	check := CheckResult{Name: "Kurtosis CLI", Status: CheckPass}
+	if _, err := exec.LookPath("kurtosis"); err != nil {
+		check.Message = "not installed, it's optional since the engine is started through Docker"
+		return check
+	}
+	check.Message = "installed"
+	// Prints "CLI Version:   X.Y.Z" on the first line
+	output, err := newCmd(ctx, "kurtosis", "version").Output()
+	if err == nil {
+		firstLine, _, _ := strings.Cut(string(output), "\n")
+		if fields := strings.Fields(firstLine); len(fields) != 0 {
+			check.Message += ", version " + fields[len(fields)-1]
+		}
+	}
+	return check
+}
+
+// Checks the Kurtosis engine's version matches the SDK's.
+// Returns whether the engine is running.
+func checkKurtosisEngine(ctx context.Context) (CheckResult, bool) {
+	check := CheckResult{Name: "Kurtosis engine"}
+	engineVersion, err := kurtosis.GetEngineVersion(ctx)
+	if errors.Is(err, kurtosis.ErrEngineNotRunning) {
+		check.Status = CheckWarn
+		check.Message = "not running, it will be started by `avs-devnet start`"
+		check.Fix = "run `avs-devnet engine start`"
+		return check, false
+	} else if err != nil {
+		check.Status = CheckFail
+		check.Message = err.Error()
+		check.Fix = "run `avs-devnet engine restart`"
+		return check, true
+	}
+	if err = kurtosis.CheckEngineVersion(engineVersion); err != nil {
+		check.Status = CheckFail
+		check.Message = err.Error()
+		check.Fix = "run `avs-devnet engine restart`"
+		return check, true
+	}
+	check.Status = CheckPass
+	check.Message = "running, version " + engineVersion
+	return check, true
+}
+
+// Checks the ports needed by the Kurtosis engine are free.
+func checkEnginePorts() CheckResult {
+	check := CheckResult{Name: "Kurtosis engine ports"}
+	busyPorts := make([]string, 0)
+	for _, port := range enginePorts {
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err != nil {
+			busyPorts = append(busyPorts, fmt.Sprint(port))
+			continue
+		}
+		_ = listener.Close()
+	}
+	if len(busyPorts) != 0 {
+		check.Status = CheckFail
+		check.Message = "ports in use by other processes: " + strings.Join(busyPorts, ", ")
+		check.Fix = "stop the processes using them (find them with `lsof -i :<port>`)"
+		return check
+	}
+	check.Status = CheckPass
+	check.Message = "ports are free"
+	return check
+}
+
+// Checks git is installed. It's used to deploy from local repos.
+func checkGit(ctx context.Context) CheckResult {
+	check := CheckResult{Name: "git"}
+	output, err := newCmd(ctx, "git", "--version").Output()
+	if err != nil {
+		check.Status = CheckWarn
+		check.Message = "git not found, it's needed to deploy contracts from local repos"
+		check.Fix = "install git: " + dependencyInstallUrls["git"]
+		return check
+	}
+	check.Status = CheckPass
+	check.Message = strings.TrimSpace(string(output))
+	return check
+}
+
+// Checks the image used to run forge was pulled.
+func checkForgeImage(ctx context.Context) CheckResult {
+	check := CheckResult{Name: "forge"}
+	if getImageId(ctx, foundryImage) == "" {
+		check.Status = CheckWarn
+		check.Message = foundryImage + " not pulled, it will be pulled when deploying from local repos"
+		check.Fix = "run `docker pull " + foundryImage + "`"
+		return check
+	}
+	check.Status = CheckPass
+	check.Message = foundryImage + " available"
+	return check
+}
+
+// Checks the devnet config can be loaded, and that its locally-built images are up to date.
+func checkConfig(ctx context.Context, configPath string, dockerRunning bool) []CheckResult {
+	check := CheckResult{Name: "Config"}
+	absPath, err := filepath.Abs(configPath)
+	if err == nil {
+		_, err = os.Stat(absPath)
+	}
+	var devnetConfig config.DevnetConfig
+	if err == nil {
+		devnetConfig, err = config.LoadFromPath(absPath)
+	}
+	if err != nil {
+		check.Status = CheckFail
+		check.Message = err.Error()
+		check.Fix = "check the config file exists and is valid"
+		return []CheckResult{check}
+	}
+	if chainType := devnetConfig.ChainType(); !slices.Contains(chainTypes, chainType) {
+		check.Status = CheckFail
+		check.Message = fmt.Sprintf("unknown chain type '%s'", chainType)
+		check.Fix = "set `chain.type` to one of: " + strings.Join(chainTypes, ", ")
+		return []CheckResult{check}
+	}
+	check.Status = CheckPass
+	check.Message = configPath + " loaded"
+	if !dockerRunning {
+		return []CheckResult{check}
+	}
+	return []CheckResult{check, checkLocalImages(ctx, filepath.Dir(absPath), devnetConfig)}
+}
+
+// Checks the locally-built images aren't outdated.
+func checkLocalImages(ctx context.Context, baseDir string, devnetConfig config.DevnetConfig) CheckResult {
+	check := CheckResult{Name: "Local images"}
+	jobs, err := collectBuildJobs(baseDir, devnetConfig.Services)
+	outdated := make([]string, 0)
+	for _, job := range jobs {
+		if err != nil {
+			break
+		}
+		var images []string
+		images, err = outdatedImages(ctx, baseDir, job)
+		outdated = append(outdated, images...)
+	}
+	switch {
+	case err != nil:
+		check.Status = CheckFail
+		check.Message = err.Error()
+		check.Fix = "check the build definitions of the services"
+	case len(outdated) != 0:
+		check.Status = CheckWarn
+		check.Message = fmt.Sprintf("outdated images: %s, they'll be rebuilt by `avs-devnet start`", strings.Join(outdated, ", "))
+		check.Fix = "if you run them outside of avs-devnet, rebuild them first"
+	default:
+		check.Status = CheckPass
+		check.Message = fmt.Sprintf("no outdated images in %d build definitions", len(jobs))
+	}
+	return check
+}
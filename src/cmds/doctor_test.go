@@ -0,0 +1,53 @@
+package cmds_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/stretchr/testify/require"
+)
+
+func findCheck(t *testing.T, checks []cmds.CheckResult, name string) cmds.CheckResult {
+	t.Helper()
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	require.Failf(t, "check not found", "no check named '%s'", name)
+	return cmds.CheckResult{}
+}
+
+func TestDoctorChecks(t *testing.T) {
+	t.Parallel()
+	checks := cmds.RunDoctor(context.Background(), filepath.Join(examplesDir, "hello_world_local.yaml"))
+	for _, check := range checks {
+		require.Contains(t, []cmds.CheckStatus{cmds.CheckPass, cmds.CheckWarn, cmds.CheckFail}, check.Status)
+		require.NotEmpty(t, check.Message, check.Name)
+		if check.Status != cmds.CheckPass {
+			require.NotEmpty(t, check.Fix, check.Name)
+		}
+	}
+	findCheck(t, checks, "Docker")
+	findCheck(t, checks, "Kurtosis engine")
+	require.Equal(t, cmds.CheckPass, findCheck(t, checks, "Config").Status)
+}
+
+func TestDoctorInvalidConfig(t *testing.T) {
+	t.Parallel()
+	checks := cmds.RunDoctor(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Equal(t, cmds.CheckFail, findCheck(t, checks, "Config").Status)
+}
+
+func TestDoctorUnknownChainType(t *testing.T) {
+	t.Parallel()
+	configPath := filepath.Join(t.TempDir(), "devnet.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("chain:\n  type: hardhat\n"), 0600))
+	checks := cmds.RunDoctor(context.Background(), configPath)
+	check := findCheck(t, checks, "Config")
+	require.Equal(t, cmds.CheckFail, check.Status)
+	require.Contains(t, check.Message, "hardhat")
+}
@@ -0,0 +1,79 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
+	"github.com/urfave/cli/v2"
+)
+
+// Starts the Kurtosis engine, if it's not already running.
+func EngineStartCmd(ctx *cli.Context) error {
+	engineVersion, err := kurtosis.GetEngineVersion(ctx.Context)
+	if err == nil {
+		fmt.Printf("Kurtosis engine already running (version %s)\n", engineVersion)
+		return engineExitError(kurtosis.CheckEngineVersion(engineVersion))
+	} else if !errors.Is(err, kurtosis.ErrEngineNotRunning) {
+		return engineExitError(err)
+	}
+	fmt.Println("Starting Kurtosis engine...")
+	if err = kurtosis.StartEngine(ctx.Context); err != nil {
+		return engineExitError(err)
+	}
+	fmt.Printf("Kurtosis engine started (version %s)\n", kurtosis.SdkVersion)
+	return nil
+}
+
+// Stops the Kurtosis engine. Running devnets are kept.
+func EngineStopCmd(ctx *cli.Context) error {
+	_, err := kurtosis.GetEngineVersion(ctx.Context)
+	if errors.Is(err, kurtosis.ErrEngineNotRunning) {
+		fmt.Println("Kurtosis engine is not running")
+		return nil
+	}
+	fmt.Println("Stopping Kurtosis engine...")
+	err = kurtosis.StopEngine(ctx.Context)
+	if errors.Is(err, kurtosis.ErrEngineNotRunning) {
+		fmt.Println("Kurtosis engine is not running")
+		return nil
+	} else if err != nil {
+		return engineExitError(err)
+	}
+	fmt.Println("Kurtosis engine stopped")
+	return nil
+}
+
+// Restarts the Kurtosis engine with the version expected by avs-devnet.
+func EngineRestartCmd(ctx *cli.Context) error {
+	fmt.Println("Restarting Kurtosis engine...")
+	if err := kurtosis.RestartEngine(ctx.Context); err != nil {
+		return engineExitError(err)
+	}
+	fmt.Printf("Kurtosis engine restarted (version %s)\n", kurtosis.SdkVersion)
+	return nil
+}
+
+// Prints whether the Kurtosis engine is running, and its version.
+// Fails if the engine's version is incompatible.
+func EngineStatusCmd(ctx *cli.Context) error {
+	engineVersion, err := kurtosis.GetEngineVersion(ctx.Context)
+	if errors.Is(err, kurtosis.ErrEngineNotRunning) {
+		fmt.Println("Kurtosis engine is not running")
+		fmt.Printf("Expected version: %s\n", kurtosis.SdkVersion)
+		return nil
+	} else if err != nil {
+		return engineExitError(err)
+	}
+	fmt.Printf("Kurtosis engine is running (version %s)\n", engineVersion)
+	fmt.Printf("Expected version: %s\n", kurtosis.SdkVersion)
+	return engineExitError(kurtosis.CheckEngineVersion(engineVersion))
+}
+
+// Converts the error into a CLI exit error, keeping nil errors as-is.
+func engineExitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return toExitError(err, "")
+}
@@ -0,0 +1,82 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes returned by the CLI, depending on the error category.
+const (
+	ExitCodeFailure           = 1
+	ExitCodeValidation        = 10
+	ExitCodeInterpretation    = 11
+	ExitCodeExecution         = 12
+	ExitCodeDependencyMissing = 20
+	ExitCodeDevnetExists      = 21
+	ExitCodeEngineVersion     = 22
+)
+
+// Installation guides for the known dependencies.
+//
+//nolint:gochecknoglobals // this is a constant
+var dependencyInstallUrls = map[string]string{
+	"docker":   "https://docs.docker.com/engine/install/",
+	"kurtosis": "https://docs.kurtosis.com/install",
+	"git":      "https://git-scm.com/downloads",
+}
+
+// Converts an error into a CLI exit error.
+// The exit code and the hint shown to the user depend on the error's type.
+func toExitError(err error, devnetName string) cli.ExitCoder {
+	code, hint := classifyError(err, devnetName)
+	if hint == "" {
+		return cli.Exit(err, code)
+	}
+	return cli.Exit(fmt.Sprintf("%s\n\nHint: %s", err, hint), code)
+}
+
+// Returns the exit code and a hint for the given error.
+func classifyError(err error, devnetName string) (int, string) {
+	var validationErr *errdefs.ValidationError
+	var interpretationErr *errdefs.InterpretationError
+	var executionErr *errdefs.ExecutionError
+	var dependencyErr *errdefs.DependencyMissingError
+	var devnetExistsErr *errdefs.DevnetExistsError
+	var engineVersionErr *errdefs.EngineVersionMismatchError
+
+	switch {
+	case errors.As(err, &devnetExistsErr):
+		hint := fmt.Sprintf("run `avs-devnet stop -n %s` first", devnetExistsErr.Name)
+		return ExitCodeDevnetExists, hint
+	case errors.As(err, &engineVersionErr):
+		hint := fmt.Sprintf(
+			"run `avs-devnet engine restart` to restart the engine with version %s",
+			engineVersionErr.ExpectedVersion,
+		)
+		return ExitCodeEngineVersion, hint
+	case errors.As(err, &dependencyErr):
+		hint := fmt.Sprintf("make sure '%s' is installed and running", dependencyErr.Dependency)
+		if installUrl, ok := dependencyInstallUrls[dependencyErr.Dependency]; ok {
+			hint += ". Installation guide: " + installUrl
+		}
+		return ExitCodeDependencyMissing, hint
+	case errors.As(err, &interpretationErr):
+		return ExitCodeInterpretation, "check the configuration file for errors"
+	case errors.As(err, &validationErr):
+		return ExitCodeValidation, "check the configuration file references existing artifacts, images and services"
+	case errors.As(err, &executionErr):
+		hint := fmt.Sprintf("check the service logs with `kurtosis enclave inspect %s`", devnetName)
+		return ExitCodeExecution, hint
+	case errors.Is(err, ErrMissingCredentials):
+		return ExitCodeFailure, "export the env var with the credentials, or remove it from the `auth` section"
+	case errors.Is(err, ErrDirtyRef):
+		return ExitCodeFailure, "commit or stash the changes, or pass --allow-dirty to deploy the ref without them"
+	case errors.Is(err, ErrEnclaveNotExists):
+		return ExitCodeFailure, "maybe it's not running?"
+	default:
+		return ExitCodeFailure, ""
+	}
+}
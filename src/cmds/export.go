@@ -0,0 +1,583 @@
+package cmds
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/Layr-Labs/avs-devnet/src/internal/maputil"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrStateDumpUnsupported = errors.New("the execution client doesn't support dumping its state")
+
+// Image of the Anvil node replacing the devnet's chain in exported devnets.
+const anvilImage = "ghcr.io/foundry-rs/foundry:v1.0.0"
+
+// Port of the execution client's RPC inside the enclave.
+// It's the same for all the clients of the ethereum-package, and for the Docker backend's Anvil node.
+const chainRpcPort = 8545
+
+// Chain ID of the ethereum-package, used by the Kurtosis package when the config doesn't set one.
+const defaultChainId = 3151908
+
+// Name of the Anvil node replacing the chain in devnets exported from their config.
+// Same as the one started by the Kurtosis package for Anvil chains.
+const plannedChainName = "el-1-anvil"
+
+// Name of the compose network the services are attached to.
+const composeNetwork = "devnet"
+
+// Paths inside the exported dir.
+const (
+	composeFileName  = "docker-compose.yaml"
+	exportArtifacts  = "artifacts"
+	exportChainState = "chain-state.json"
+)
+
+// Options accepted by ExportCompose.
+type ExportComposeOptions struct {
+	// Config the devnet was started with
+	DevnetConfig config.DevnetConfig
+	// Path to the config's dir, used when resolving relative paths
+	WorkingDir string
+	// Dir to write the compose file and artifacts to. Must not exist.
+	OutputDir string
+}
+
+// Exports the devnet as a docker-compose project.
+// If it isn't running, it's exported from its config only.
+func ExportComposeCmd(ctx *cli.Context) error {
+	devnetName := flags.DevnetNameFlag.Get(ctx)
+	configPath, err := parseConfigFileName(ctx)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	devnetConfig, err := config.LoadFromPath(configPath)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	workingDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+	outputDir := flags.OutputFlag.Get(ctx)
+	if outputDir == "" {
+		outputDir = devnetName + "-compose"
+	}
+	b, err := backend.New(flags.BackendFlag.Get(ctx))
+	if err != nil {
+		return toExitError(err, devnetName)
+	}
+	opts := ExportComposeOptions{DevnetConfig: devnetConfig, WorkingDir: workingDir, OutputDir: outputDir}
+	var warnings []string
+	if b.EnclaveExists(ctx.Context, devnetName) {
+		var enclave backend.Enclave
+		enclave, err = b.GetEnclave(ctx.Context, devnetName)
+		if err != nil {
+			return toExitError(err, devnetName)
+		}
+		warnings, err = ExportCompose(ctx.Context, enclave, opts)
+	} else {
+		fmt.Printf("Devnet '%s' isn't running, so it's exported from its config only.\n", devnetName)
+		warnings, err = ExportPlannedCompose(devnetName, opts)
+	}
+	if err != nil {
+		return toExitError(err, devnetName)
+	}
+	for _, warning := range warnings {
+		fmt.Println("WARNING: " + warning)
+	}
+	fmt.Printf("Devnet exported to '%s'. Start it with:\n\n", outputDir)
+	fmt.Printf("  docker compose -f %s up\n", filepath.Join(outputDir, composeFileName))
+	return nil
+}
+
+// The subset of the compose file format used by the export.
+type composeProject struct {
+	Name     string                    `yaml:"name"`
+	Services map[string]composeService `yaml:"services"`
+	Networks map[string]composeNet     `yaml:"networks"`
+}
+
+type composeService struct {
+	Image       string                       `yaml:"image"`
+	Build       *composeBuild                `yaml:"build,omitempty"`
+	Entrypoint  []string                     `yaml:"entrypoint,omitempty"`
+	Command     []string                     `yaml:"command,omitempty"`
+	Environment map[string]string            `yaml:"environment,omitempty"`
+	Ports       []string                     `yaml:"ports,omitempty"`
+	Volumes     []string                     `yaml:"volumes,omitempty"`
+	User        string                       `yaml:"user,omitempty"`
+	DependsOn   []string                     `yaml:"depends_on,omitempty"`
+	Networks    map[string]composeNetAddress `yaml:"networks"`
+}
+
+type composeBuild struct {
+	Context    string `yaml:"context"`
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+}
+
+type composeNetAddress struct {
+	Ipv4Address string `yaml:"ipv4_address,omitempty"`
+}
+
+type composeNet struct {
+	Ipam *composeIpam `yaml:"ipam,omitempty"`
+}
+
+type composeIpam struct {
+	Config []composeSubnet `yaml:"config"`
+}
+
+type composeSubnet struct {
+	Subnet string `yaml:"subnet"`
+}
+
+// Writes a docker-compose project reproducing the devnet's services into `opts.OutputDir`.
+// Templated env vars and args are replaced with the values they resolved to in the devnet,
+// and the services' inputs are downloaded and bind-mounted. Services keep their IP addresses,
+// so the resolved values stay valid. The chain is replaced by an Anvil node, loaded with the
+// chain's state if the execution client supports dumping it.
+// Returns warnings about the parts of the devnet that can't be reproduced.
+func ExportCompose(ctx context.Context, enclave backend.Enclave, opts ExportComposeOptions) ([]string, error) {
+	if _, err := os.Stat(opts.OutputDir); err == nil {
+		return nil, fmt.Errorf("output dir '%s' already exists", opts.OutputDir)
+	}
+	services, err := enclave.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	servicesByName := make(map[string]backend.Service, len(services))
+	ipAddresses := make([]string, 0, len(services))
+	for _, service := range services {
+		servicesByName[service.Name] = service
+		if service.IPAddress != "" {
+			ipAddresses = append(ipAddresses, service.IPAddress)
+		}
+	}
+	chain, ok := findChainService(services)
+	if !ok {
+		return nil, errors.New("the devnet has no execution client")
+	}
+	subnet, err := subnetFor(ipAddresses)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, err
+	}
+	exporter := &composeExporter{
+		ctx:        ctx,
+		enclave:    enclave,
+		opts:       opts,
+		downloaded: make(map[string]bool),
+	}
+	project := composeProject{
+		Name:     enclave.Name(),
+		Services: make(map[string]composeService),
+		Networks: map[string]composeNet{
+			composeNetwork: {Ipam: &composeIpam{Config: []composeSubnet{{Subnet: subnet}}}},
+		},
+	}
+	chainService, err := exporter.chainService(chain)
+	if err != nil {
+		return nil, err
+	}
+	project.Services[chain.Name] = chainService
+	for _, serviceConfig := range opts.DevnetConfig.Services {
+		service, serviceErr := exporter.service(serviceConfig, servicesByName[serviceConfig.Name])
+		if serviceErr != nil {
+			return nil, fmt.Errorf("failed to export service '%s': %w", serviceConfig.Name, serviceErr)
+		}
+		service.DependsOn = []string{chain.Name}
+		project.Services[serviceConfig.Name] = service
+	}
+	header := "# Exported from the devnet '" + enclave.Name() + "' by `avs-devnet export compose`."
+	err = writeComposeProject(opts.OutputDir, project, header)
+	return exporter.warnings, err
+}
+
+// Writes a docker-compose project reproducing the devnet planned by the config into `opts.OutputDir`,
+// without starting it. Services are attached to the chain's network without fixed IP addresses.
+// Since templated env vars and args, and the services' inputs, are only generated when the devnet starts,
+// templates are kept as-is and inputs aren't exported. The chain is an empty Anvil node with the config's chain ID.
+// Returns warnings about the parts of the devnet that can't be reproduced.
+func ExportPlannedCompose(devnetName string, opts ExportComposeOptions) ([]string, error) {
+	if _, err := os.Stat(opts.OutputDir); err == nil {
+		return nil, fmt.Errorf("output dir '%s' already exists", opts.OutputDir)
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, err
+	}
+	exporter := &composeExporter{opts: opts, downloaded: make(map[string]bool)}
+	project := composeProject{
+		Name:     devnetName,
+		Services: make(map[string]composeService),
+		Networks: map[string]composeNet{composeNetwork: {}},
+	}
+	project.Services[plannedChainName] = composeService{
+		Image:      anvilImage,
+		Entrypoint: []string{"anvil"},
+		Command: []string{
+			"--host", "0.0.0.0",
+			"--port", strconv.Itoa(chainRpcPort),
+			"--chain-id", plannedChainId(opts.DevnetConfig),
+		},
+		Ports:    []string{fmt.Sprintf("%d/tcp", chainRpcPort)},
+		Networks: map[string]composeNetAddress{composeNetwork: {}},
+	}
+	exporter.warn("the chain starts empty, so contracts need to be deployed and the deployer account funded")
+	for _, serviceConfig := range opts.DevnetConfig.Services {
+		service, err := exporter.service(serviceConfig, backend.Service{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export service '%s': %w", serviceConfig.Name, err)
+		}
+		service.DependsOn = []string{plannedChainName}
+		project.Services[serviceConfig.Name] = service
+	}
+	header := "# Exported from the config of the devnet '" + devnetName + "' by `avs-devnet export compose`."
+	err := writeComposeProject(opts.OutputDir, project, header)
+	return exporter.warnings, err
+}
+
+// Writes the compose file, starting with the given comment.
+func writeComposeProject(outputDir string, project composeProject, header string) error {
+	contents, err := yaml.Marshal(project)
+	if err != nil {
+		return err
+	}
+	contents = append([]byte(header+"\n"), contents...)
+	return os.WriteFile(filepath.Join(outputDir, composeFileName), contents, 0600)
+}
+
+// Returns the chain ID the Kurtosis package uses for the config's chain.
+func plannedChainId(devnetConfig config.DevnetConfig) string {
+	if devnetConfig.ChainType() == config.ChainTypeAnvil && devnetConfig.Chain.ChainId != nil {
+		return strconv.FormatUint(*devnetConfig.Chain.ChainId, 10)
+	}
+	if devnetConfig.EthereumPackage != nil {
+		if networkId, ok := devnetConfig.EthereumPackage.NetworkParams["network_id"]; ok {
+			return starlarkStr(networkId)
+		}
+	}
+	return strconv.Itoa(defaultChainId)
+}
+
+// Exports the devnet's services, downloading the artifacts they need.
+// Without an enclave, services are exported from their config only.
+type composeExporter struct {
+	ctx      context.Context
+	enclave  backend.Enclave
+	opts     ExportComposeOptions
+	warnings []string
+	// Artifacts already downloaded, by name
+	downloaded map[string]bool
+	// Number of mounts merging multiple artifacts
+	mergedMounts int
+}
+
+// Returns an Anvil node replacing the chain, with the same chain ID and IP address.
+func (e *composeExporter) chainService(chain backend.Service) (composeService, error) {
+	rpcEndpoint, ok := chain.Ports["rpc"]
+	if !ok {
+		return composeService{}, fmt.Errorf("execution client '%s' has no RPC port", chain.Name)
+	}
+	client, err := rpc.DialContext(e.ctx, "http://"+rpcEndpoint)
+	if err != nil {
+		return composeService{}, fmt.Errorf("failed to connect to the execution client: %w", err)
+	}
+	defer client.Close()
+	var chainId hexutil.Big
+	if err = client.CallContext(e.ctx, &chainId, "eth_chainId"); err != nil {
+		return composeService{}, fmt.Errorf("failed to get the chain ID: %w", err)
+	}
+	command := []string{
+		"--host", "0.0.0.0",
+		"--port", strconv.Itoa(chainRpcPort),
+		"--chain-id", (*big.Int)(&chainId).String(),
+	}
+	var volumes []string
+	state, err := dumpChainState(e.ctx, client)
+	switch {
+	case errors.Is(err, ErrStateDumpUnsupported):
+		e.warn("the execution client doesn't support `anvil_dumpState`, so the chain starts empty. " +
+			"Contracts need to be deployed again, and the deployer account needs to be funded.")
+	case err != nil:
+		return composeService{}, err
+	default:
+		if err = os.WriteFile(filepath.Join(e.opts.OutputDir, exportChainState), state, 0600); err != nil {
+			return composeService{}, err
+		}
+		statePath := "/state/" + exportChainState
+		volumes = append(volumes, "./"+exportChainState+":"+statePath)
+		command = append(command, "--load-state", statePath)
+	}
+	if wsEndpoint, ok := chain.Ports["ws"]; ok && wsEndpoint != rpcEndpoint {
+		e.warn(fmt.Sprintf("Anvil serves WebSockets on the RPC port, so `ws_rpc_url` values need port %d", chainRpcPort))
+	}
+	return composeService{
+		Image:      anvilImage,
+		Entrypoint: []string{"anvil"},
+		Command:    command,
+		Ports:      []string{fmt.Sprintf("%d/tcp", chainRpcPort)},
+		Volumes:    volumes,
+		Networks:   map[string]composeNetAddress{composeNetwork: {Ipv4Address: chain.IPAddress}},
+	}, nil
+}
+
+// Returns the compose definition of a service from the config.
+func (e *composeExporter) service(serviceConfig config.Service, running backend.Service) (composeService, error) {
+	name := serviceConfig.Name
+	service := composeService{
+		Image:       serviceConfig.Image,
+		Environment: make(map[string]string, len(serviceConfig.Env)),
+		// Same as in the Kurtosis package, since artifacts are owned by root
+		User:     "0:0",
+		Networks: map[string]composeNetAddress{composeNetwork: {Ipv4Address: running.IPAddress}},
+	}
+	if e.enclave != nil && running.Name == "" {
+		e.warn(fmt.Sprintf("service '%s' isn't running in the devnet, so its IP address may change", name))
+	}
+	if serviceConfig.BuildContext != nil {
+		buildContext, err := e.relativePath(filepath.Join(e.opts.WorkingDir, *serviceConfig.BuildContext))
+		if err != nil {
+			return service, err
+		}
+		service.Build = &composeBuild{Context: buildContext}
+		if serviceConfig.BuildFile != nil {
+			service.Build.Dockerfile = *serviceConfig.BuildFile
+		}
+	}
+	for _, key := range maputil.SortedKeys(serviceConfig.Env) {
+		resolved, err := e.resolve(serviceConfig.Env[key], "service_"+name+"_expanded_env_var_"+key,
+			fmt.Sprintf("env var '%s' of service '%s'", key, name))
+		if err != nil {
+			return service, fmt.Errorf("failed to resolve env var '%s': %w", key, err)
+		}
+		service.Environment[key] = escapeCompose(resolved)
+	}
+	for i, arg := range serviceConfig.Cmd {
+		resolved, err := e.resolve(arg, "service_"+name+"_expanded_cmd_"+strconv.Itoa(i),
+			fmt.Sprintf("cmd arg %d of service '%s'", i, name))
+		if err != nil {
+			return service, fmt.Errorf("failed to resolve cmd arg %d: %w", i, err)
+		}
+		service.Command = append(service.Command, escapeCompose(resolved))
+	}
+	for _, portName := range maputil.SortedKeys(serviceConfig.Ports) {
+		port := serviceConfig.Ports[portName]
+		protocol := strings.ToLower(port.TransportProtocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		service.Ports = append(service.Ports, fmt.Sprintf("%d/%s", port.Number, protocol))
+	}
+	if e.enclave == nil && len(serviceConfig.Input) != 0 {
+		e.warn(fmt.Sprintf("the inputs of service '%s' are generated when the devnet starts, so they aren't mounted", name))
+	}
+	for _, mountPath := range maputil.SortedKeys(serviceConfig.Input) {
+		hostPath, err := e.mountArtifacts(serviceConfig.Input[mountPath])
+		if err != nil {
+			return service, err
+		}
+		if hostPath != "" {
+			service.Volumes = append(service.Volumes, hostPath+":"+mountPath)
+		}
+	}
+	return service, nil
+}
+
+// Returns the value a config value resolved to in the devnet.
+// Templated values are read from the artifact the Kurtosis package stored them in.
+// Without an enclave, they're returned as-is with a warning mentioning `description`.
+func (e *composeExporter) resolve(value interface{}, artifactName string, description string) (string, error) {
+	str := starlarkStr(value)
+	if !strings.Contains(str, "{{") {
+		return str, nil
+	}
+	if e.enclave == nil {
+		e.warn(description + " has templates, which are only resolved when the devnet starts")
+		return str, nil
+	}
+	files, err := e.enclave.InspectArtifact(e.ctx, artifactName)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if file.Path == "expanded.txt" {
+			return file.TextPreview, nil
+		}
+	}
+	return "", fmt.Errorf("artifact '%s' has no expanded value", artifactName)
+}
+
+// Downloads the artifacts to mount at a single path, returning the host path to mount.
+// Multiple artifacts are merged into a single dir.
+func (e *composeExporter) mountArtifacts(artifactNames config.ArtifactNames) (string, error) {
+	if e.enclave == nil {
+		return "", nil
+	}
+	for _, artifactName := range artifactNames {
+		if err := e.download(artifactName); err != nil {
+			return "", err
+		}
+	}
+	switch len(artifactNames) {
+	case 0:
+		return "", nil
+	case 1:
+		return "./" + exportArtifacts + "/" + artifactNames[0], nil
+	}
+	e.mergedMounts += 1
+	mergedName := fmt.Sprintf("merged-%d", e.mergedMounts)
+	mergedDir := filepath.Join(e.opts.OutputDir, exportArtifacts, "_merged", mergedName)
+	for _, artifactName := range artifactNames {
+		artifactDir := filepath.Join(e.opts.OutputDir, exportArtifacts, artifactName)
+		err := fsutil.CopyDir(artifactDir, mergedDir, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "./" + exportArtifacts + "/_merged/" + mergedName, nil
+}
+
+func (e *composeExporter) download(artifactName string) error {
+	if e.downloaded[artifactName] {
+		return nil
+	}
+	if err := checkArtifactName(artifactName); err != nil {
+		return err
+	}
+	dstDir := filepath.Join(e.opts.OutputDir, exportArtifacts, artifactName)
+	if err := e.enclave.DownloadArtifact(e.ctx, artifactName, dstDir); err != nil {
+		return fmt.Errorf("failed to download artifact '%s': %w", artifactName, err)
+	}
+	e.downloaded[artifactName] = true
+	return nil
+}
+
+// Fails if the artifact's name can't be used as a dir name.
+func checkArtifactName(artifactName string) error {
+	if artifactName == "" || strings.ContainsAny(artifactName, `/\`) || strings.HasPrefix(artifactName, ".") {
+		return fmt.Errorf("invalid artifact name '%s'", artifactName)
+	}
+	return nil
+}
+
+// Returns the path relative to the output dir, as used in the compose file.
+func (e *composeExporter) relativePath(path string) (string, error) {
+	absOutputDir, err := filepath.Abs(e.opts.OutputDir)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(absOutputDir, path)
+	if err != nil {
+		return "", err
+	}
+	relPath = filepath.ToSlash(relPath)
+	if strings.HasPrefix(relPath, "../") {
+		return relPath, nil
+	}
+	return "./" + relPath, nil
+}
+
+func (e *composeExporter) warn(message string) {
+	e.warnings = append(e.warnings, message)
+}
+
+// Returns the state of the chain, as loaded by `anvil --load-state`.
+// Fails with ErrStateDumpUnsupported if the execution client isn't Anvil.
+func dumpChainState(ctx context.Context, client *rpc.Client) ([]byte, error) {
+	var state hexutil.Bytes
+	err := client.CallContext(ctx, &state, "anvil_dumpState")
+	if errdefs.IsMethodNotFound(err) {
+		return nil, fmt.Errorf("%w: %w", ErrStateDumpUnsupported, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to dump the chain's state: %w", err)
+	}
+	// Anvil returns the state gzipped
+	reader, err := gzip.NewReader(bytes.NewReader(state))
+	if err != nil {
+		return state, nil //nolint:nilerr // the state isn't compressed
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// Returns the service running the first execution client.
+// The ethereum-package names them like "el-1-reth-lighthouse".
+func findChainService(services []backend.Service) (backend.Service, bool) {
+	var chain backend.Service
+	for _, service := range services {
+		if strings.HasPrefix(service.Name, "el-1-") && (chain.Name == "" || service.Name < chain.Name) {
+			chain = service
+		}
+	}
+	return chain, chain.Name != ""
+}
+
+// Returns the /16 subnet containing all the given IPv4 addresses.
+func subnetFor(ipAddresses []string) (string, error) {
+	const prefixLen = 16
+	var subnet *net.IPNet
+	for _, address := range ipAddresses {
+		ip := net.ParseIP(address).To4()
+		if ip == nil {
+			return "", fmt.Errorf("invalid IPv4 address '%s'", address)
+		}
+		if subnet == nil {
+			mask := net.CIDRMask(prefixLen, 8*net.IPv4len)
+			subnet = &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		} else if !subnet.Contains(ip) {
+			return "", fmt.Errorf("the services' IP addresses aren't in the same /%d subnet", prefixLen)
+		}
+	}
+	if subnet == nil {
+		return "", errors.New("the devnet's services have no IP addresses")
+	}
+	return subnet.String(), nil
+}
+
+// Formats a scalar config value like Starlark's `str`, which the Kurtosis package uses for non-strings.
+func starlarkStr(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		if value {
+			return "True"
+		}
+		return "False"
+	case float64:
+		str := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+		return str
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Escapes the `$` characters, which compose would interpolate.
+func escapeCompose(value string) string {
+	return strings.ReplaceAll(value, "$", "$$")
+}
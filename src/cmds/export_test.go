@@ -0,0 +1,155 @@
+package cmds_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const exportConfig = `
+services:
+  - name: aggregator
+    image: aggregator:latest
+    ports:
+      rpc:
+        number: 8090
+        transport_protocol: TCP
+    env:
+      DEBUG: 1
+      RPC_URL: "{{.http_rpc_url}}"
+    cmd: ["sh", "-c", "echo $RPC_URL"]
+    input:
+      /config/: aggregator_config
+      /keys/: [aggregator_bls, aggregator_ecdsa]
+`
+
+func TestExportCompose(t *testing.T) {
+	t.Parallel()
+	chain := backendtest.NewStubChain(t, true)
+	fake := backendtest.NewFake()
+	enclave := fake.AddEnclave("devnet")
+	enclave.SetServices(chain.Service(), backend.Service{Name: "aggregator", IPAddress: "172.16.0.3"})
+	enclave.SetArtifact("service_aggregator_expanded_env_var_RPC_URL",
+		backend.ArtifactFile{Path: "expanded.txt", TextPreview: "http://172.16.0.2:8545"})
+	enclave.SetArtifact("aggregator_config", backend.ArtifactFile{Path: "config.yaml", TextPreview: "port: 8090"})
+	enclave.SetArtifact("aggregator_bls", backend.ArtifactFile{Path: "bls.json", TextPreview: "{}"})
+	enclave.SetArtifact("aggregator_ecdsa", backend.ArtifactFile{Path: "ecdsa.json", TextPreview: "{}"})
+
+	devnetConfig, err := config.Unmarshal([]byte(exportConfig))
+	require.NoError(t, err)
+	outputDir := filepath.Join(t.TempDir(), "compose")
+	opts := cmds.ExportComposeOptions{DevnetConfig: devnetConfig, WorkingDir: t.TempDir(), OutputDir: outputDir}
+	warnings, err := cmds.ExportCompose(context.Background(), enclave, opts)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	contents, err := os.ReadFile(filepath.Join(outputDir, "docker-compose.yaml"))
+	require.NoError(t, err)
+	var project struct {
+		Services map[string]struct {
+			Command     []string
+			Environment map[string]string
+			Ports       []string
+			Volumes     []string
+			Networks    map[string]struct {
+				Ipv4Address string `yaml:"ipv4_address"`
+			}
+		}
+		Networks map[string]any
+	}
+	require.NoError(t, yaml.Unmarshal(contents, &project))
+
+	chainService := project.Services["el-1-anvil"]
+	require.Equal(t, []string{
+		"--host", "0.0.0.0", "--port", "8545", "--chain-id", "3151908", "--load-state", "/state/chain-state.json",
+	}, chainService.Command)
+	require.Equal(t, backendtest.StubChainIP, chainService.Networks["devnet"].Ipv4Address)
+	state, err := os.ReadFile(filepath.Join(outputDir, "chain-state.json"))
+	require.NoError(t, err)
+	require.Equal(t, chain.State(), string(state))
+
+	aggregator := project.Services["aggregator"]
+	require.Equal(t, map[string]string{"DEBUG": "1", "RPC_URL": "http://172.16.0.2:8545"}, aggregator.Environment)
+	require.Equal(t, []string{"sh", "-c", "echo $$RPC_URL"}, aggregator.Command)
+	require.Equal(t, []string{"8090/tcp"}, aggregator.Ports)
+	require.Equal(t, []string{
+		"./artifacts/aggregator_config:/config/",
+		"./artifacts/_merged/merged-1:/keys/",
+	}, aggregator.Volumes)
+	require.Equal(t, "172.16.0.3", aggregator.Networks["devnet"].Ipv4Address)
+	require.FileExists(t, filepath.Join(outputDir, "artifacts", "aggregator_config", "config.yaml"))
+	require.FileExists(t, filepath.Join(outputDir, "artifacts", "_merged", "merged-1", "bls.json"))
+	require.FileExists(t, filepath.Join(outputDir, "artifacts", "_merged", "merged-1", "ecdsa.json"))
+
+	_, err = cmds.ExportCompose(context.Background(), enclave, opts)
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestExportPlannedCompose(t *testing.T) {
+	t.Parallel()
+	devnetConfig, err := config.Unmarshal([]byte(exportConfig + `
+  - name: operator
+    image: operator
+    build_context: operator
+    build_file: operator.Dockerfile
+
+ethereum_package:
+  network_params:
+    network_id: "31337"
+`))
+	require.NoError(t, err)
+	workingDir := t.TempDir()
+	outputDir := filepath.Join(workingDir, "compose")
+	opts := cmds.ExportComposeOptions{DevnetConfig: devnetConfig, WorkingDir: workingDir, OutputDir: outputDir}
+	warnings, err := cmds.ExportPlannedCompose("devnet", opts)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		"the chain starts empty, so contracts need to be deployed and the deployer account funded",
+		"env var 'RPC_URL' of service 'aggregator' has templates, which are only resolved when the devnet starts",
+		"the inputs of service 'aggregator' are generated when the devnet starts, so they aren't mounted",
+	}, warnings)
+
+	contents, err := os.ReadFile(filepath.Join(outputDir, "docker-compose.yaml"))
+	require.NoError(t, err)
+	var project struct {
+		Services map[string]struct {
+			Image       string
+			Build       map[string]string
+			Command     []string
+			Environment map[string]string
+			Ports       []string
+			Volumes     []string
+			DependsOn   []string `yaml:"depends_on"`
+			Networks    map[string]map[string]string
+		}
+		Networks map[string]map[string]any
+	}
+	require.NoError(t, yaml.Unmarshal(contents, &project))
+	require.Equal(t, map[string]map[string]any{"devnet": {}}, project.Networks)
+
+	chainService := project.Services["el-1-anvil"]
+	require.Equal(t, []string{"--host", "0.0.0.0", "--port", "8545", "--chain-id", "31337"}, chainService.Command)
+	require.Empty(t, chainService.Volumes)
+
+	aggregator := project.Services["aggregator"]
+	require.Equal(t, "aggregator:latest", aggregator.Image)
+	require.Equal(t, map[string]string{"DEBUG": "1", "RPC_URL": "{{.http_rpc_url}}"}, aggregator.Environment)
+	require.Equal(t, []string{"sh", "-c", "echo $$RPC_URL"}, aggregator.Command)
+	require.Equal(t, []string{"8090/tcp"}, aggregator.Ports)
+	require.Empty(t, aggregator.Volumes)
+	require.Equal(t, []string{"el-1-anvil"}, aggregator.DependsOn)
+	require.Empty(t, aggregator.Networks["devnet"])
+
+	operator := project.Services["operator"]
+	require.Equal(t, "operator", operator.Image)
+	require.Equal(t, map[string]string{"context": "../operator", "dockerfile": "operator.Dockerfile"}, operator.Build)
+	require.NoDirExists(t, filepath.Join(outputDir, "artifacts"))
+}
@@ -8,6 +8,9 @@ import "github.com/urfave/cli/v2"
 //nolint:gochecknoglobals // this is a constant
 var DefaultKurtosisPackage string = "github.com/Layr-Labs/avs-devnet/kurtosis_package"
 
+// Default value for JobsFlag.
+const DefaultJobs = 4
+
 //nolint:gochecknoglobals // these are constants
 var (
 	DevnetNameFlag = cli.StringFlag{
@@ -19,6 +22,73 @@ var (
 		DefaultText: "devnet",
 	}
 
+	FromSnapshotFlag = cli.StringFlag{
+		Name:      "from-snapshot",
+		TakesFile: true,
+		Usage:     "Start from a snapshot saved with `snapshot save`, skipping the deployments",
+	}
+
+	KeepOnFailureFlag = cli.BoolFlag{
+		Name:  "keep-on-failure",
+		Usage: "Keep the devnet running if the start fails or is interrupted, for debugging",
+	}
+
+	VerboseFlag = cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "Print the full output of image builds and Kurtosis instructions",
+	}
+
+	RebuildFlag = cli.BoolFlag{
+		Name:  "rebuild",
+		Usage: "Rebuild local images and scripts even if their inputs didn't change",
+	}
+
+	AllowDirtyFlag = cli.BoolFlag{
+		Name:  "allow-dirty",
+		Usage: "Allow deploying local repo refs that are checked out with uncommitted changes",
+	}
+
+	JobsFlag = cli.IntFlag{
+		Name:    "jobs",
+		Aliases: []string{"j", "build-jobs"},
+		Usage:   "Maximum number of image builds, uploads and downloads to run in parallel",
+		Value:   DefaultJobs,
+	}
+
+	StrictResponsesFlag = cli.BoolFlag{
+		Name:    "strict-responses",
+		Usage:   "Fail on Kurtosis responses this version doesn't recognize, instead of skipping them with a warning",
+		EnvVars: []string{"AVS_DEVNET__STRICT_RESPONSES"},
+	}
+
+	BackendFlag = cli.StringFlag{
+		Name: "backend",
+		Usage: "Runtime to run the devnet in: 'kurtosis' or 'docker'. " +
+			"The Docker backend runs plain Docker containers, with a single Anvil node as the chain, " +
+			"so ethereum_package args other than network_params.network_id and network_params.seconds_per_slot " +
+			"are ignored",
+		EnvVars: []string{"AVS_DEVNET__BACKEND"},
+		Value:   "kurtosis",
+	}
+
+	FastFlag = cli.BoolFlag{
+		Name:  "fast",
+		Usage: "Use a single Anvil node as the chain, which is faster to start than a full network",
+	}
+
+	OutputFlag = cli.StringFlag{
+		Name:        "output",
+		Aliases:     []string{"o"},
+		TakesFile:   true,
+		Usage:       "Dir to write the output to",
+		DefaultText: "<name>-compose",
+	}
+
+	JsonFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print the output as JSON",
+	}
+
 	// NOTE: this flag is for internal use.
 	// This flag/envvar allows us to override the Kurtosis package to local copies for development.
 	// This envvar is set when running `source env.sh`.
@@ -0,0 +1,50 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+)
+
+// Foundry image used to run forge.
+// Keep in sync with FOUNDRY_VERSION in kurtosis_package/dockerfiles/contract_deployer.Dockerfile.
+const foundryImage = "ghcr.io/foundry-rs/foundry:v1.0.0"
+
+// Returns a command that runs forge inside the foundry image, with `workDir` as working directory.
+// The given dirs are bind-mounted at the same paths inside the container, so paths don't need translation.
+func newForgeCmd(ctx context.Context, workDir string, mountDirs []string, forgeArgs ...string) (*exec.Cmd, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	args := []string{
+		"run", "--rm",
+		"--workdir", workDir,
+		"--entrypoint", "forge",
+		// Run as the current user, so created files aren't owned by root
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--env", "HOME=/tmp",
+		// Git refuses to work on repos owned by other users
+		"--env", "GIT_CONFIG_COUNT=1",
+		"--env", "GIT_CONFIG_KEY_0=safe.directory",
+		"--env", "GIT_CONFIG_VALUE_0=*",
+	}
+	for _, dir := range mountDirs {
+		args = append(args, "--volume", dir+":"+dir)
+	}
+	args = append(args, foundryImage)
+	args = append(args, forgeArgs...)
+	return newCmd(ctx, "docker", args...), nil
+}
+
+// Runs forge inside the foundry image and returns its combined output.
+// See newForgeCmd for details.
+func runForge(ctx context.Context, workDir string, mountDirs []string, forgeArgs ...string) ([]byte, error) {
+	cmd, err := newForgeCmd(ctx, workDir, mountDirs, forgeArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.CombinedOutput()
+}
@@ -1,13 +1,13 @@
 package cmds
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
 	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
-	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
-	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/services"
 	"github.com/tidwall/gjson"
 	"github.com/urfave/cli/v2"
 )
@@ -16,16 +16,12 @@ func GetAddress(ctx *cli.Context) error {
 	args := ctx.Args()
 	devnetName := flags.DevnetNameFlag.Get(ctx)
 
-	kurtosisCtx, err := kurtosis.InitKurtosisContext()
+	enclave, err := openEnclave(ctx, devnetName)
 	if err != nil {
-		return cli.Exit(err, 1)
-	}
-	enclaveCtx, err := kurtosisCtx.GetEnclaveCtx(ctx.Context, devnetName)
-	if err != nil {
-		return cli.Exit(err.Error()+"\n\nFailed to find devnet '"+devnetName+"'. Maybe it's not running?", 1)
+		return err
 	}
 
-	err = printAddresses(ctx, args.Slice(), enclaveCtx)
+	err = printAddresses(ctx.Context, args.Slice(), enclave)
 
 	if err != nil {
 		return cli.Exit(err, 1)
@@ -33,7 +29,7 @@ func GetAddress(ctx *cli.Context) error {
 	return nil
 }
 
-func printAddresses(ctx *cli.Context, args []string, enclaveCtx kurtosis.EnclaveCtx) error {
+func printAddresses(ctx context.Context, args []string, enclave backend.Enclave) error {
 	failed := false
 	cached := make(map[string]string)
 
@@ -50,7 +46,7 @@ func printAddresses(ctx *cli.Context, args []string, enclaveCtx kurtosis.Enclave
 		contractName := path[1]
 		file, ok := cached[artifactName]
 		if !ok {
-			readFile, err := readJsonArtifact(ctx, enclaveCtx, artifactName)
+			readFile, err := ReadJsonArtifact(ctx, enclave, artifactName)
 			if err != nil {
 				fmt.Println("Error reading artifact", artifactName+":", err)
 				failed = true
@@ -59,7 +55,7 @@ func printAddresses(ctx *cli.Context, args []string, enclaveCtx kurtosis.Enclave
 			file = readFile
 			cached[artifactName] = file
 		}
-		output, ok := readArtifact(file, contractName)
+		output, ok := LookupAddress(file, contractName)
 		if !ok {
 			fmt.Println("Error getting", arg)
 			failed = true
@@ -73,7 +69,11 @@ func printAddresses(ctx *cli.Context, args []string, enclaveCtx kurtosis.Enclave
 	return nil
 }
 
-func readArtifact(file string, contractName string) (string, bool) {
+// Looks up a contract's address inside the contents of a JSON artifact.
+// A `contractName` starting with "." is used as a path under "addresses",
+// otherwise the first entry with that name is returned.
+// If empty, the whole (pretty-printed) JSON is returned.
+func LookupAddress(file string, contractName string) (string, bool) {
 	var jsonPath string
 	switch {
 	case strings.HasPrefix(contractName, "."):
@@ -94,14 +94,15 @@ func readArtifact(file string, contractName string) (string, bool) {
 	return res.String(), true
 }
 
-func readJsonArtifact(ctx *cli.Context, enclaveCtx kurtosis.EnclaveCtx, artifactName string) (string, error) {
-	artifactInfo, err := enclaveCtx.InspectFilesArtifact(ctx.Context, services.FileArtifactName(artifactName))
+// Returns the contents of the first JSON file inside the artifact.
+func ReadJsonArtifact(ctx context.Context, enclave backend.Enclave, artifactName string) (string, error) {
+	files, err := enclave.InspectArtifact(ctx, artifactName)
 	if err != nil {
 		return "", err
 	}
-	for _, file := range artifactInfo.GetFileDescriptions() {
-		if strings.HasSuffix(file.GetPath(), ".json") {
-			return file.GetTextPreview(), nil
+	for _, file := range files {
+		if strings.HasSuffix(file.Path, ".json") {
+			return file.TextPreview, nil
 		}
 	}
 	return "", errors.New("No json file found in artifact " + artifactName)
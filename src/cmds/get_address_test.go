@@ -0,0 +1,61 @@
+package cmds_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/stretchr/testify/require"
+)
+
+const addressesJson = `{
+  "addresses": {
+    "delegation": "0x9f9F5Fd89ad648f2C000C954d8d9C87743243eC5",
+    "strategies": {"MockETH": "0x2b45cD38B213Bbd3A1A848bf2467927c976877Cb"}
+  }
+}`
+
+func TestReadAddresses(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	enclave := fake.AddEnclave("devnet")
+	enclave.SetArtifact("eigenlayer_addresses",
+		backend.ArtifactFile{Path: "README.md", TextPreview: "not json"},
+		backend.ArtifactFile{Path: "output/31337.json", TextPreview: addressesJson},
+	)
+
+	file, err := cmds.ReadJsonArtifact(context.Background(), enclave, "eigenlayer_addresses")
+	require.NoError(t, err)
+
+	address, ok := cmds.LookupAddress(file, "delegation")
+	require.True(t, ok)
+	require.Equal(t, "0x9f9F5Fd89ad648f2C000C954d8d9C87743243eC5", address)
+
+	address, ok = cmds.LookupAddress(file, "MockETH")
+	require.True(t, ok)
+	require.Equal(t, "0x2b45cD38B213Bbd3A1A848bf2467927c976877Cb", address)
+
+	_, ok = cmds.LookupAddress(file, ".MockETH")
+	require.False(t, ok)
+
+	_, err = cmds.ReadJsonArtifact(context.Background(), enclave, "missing")
+	require.ErrorIs(t, err, backendtest.ErrNotFound)
+}
+
+func TestGetServicePorts(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	enclave := fake.AddEnclave("devnet")
+	enclave.SetServices(backend.Service{
+		Name:  "el-1-reth-lighthouse",
+		Ports: map[string]string{"rpc": "127.0.0.1:8545", "ws": "127.0.0.1:8546"},
+	})
+
+	ports, err := cmds.GetServicePorts(context.Background(), enclave)
+	require.NoError(t, err)
+	require.Equal(t, map[string]cmds.ServicePorts{
+		"el-1-reth-lighthouse": {"rpc": "127.0.0.1:8545", "ws": "127.0.0.1:8546"},
+	}, ports)
+}
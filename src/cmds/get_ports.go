@@ -1,10 +1,11 @@
 package cmds
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
 	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
-	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 )
@@ -12,15 +13,11 @@ import (
 func GetPorts(ctx *cli.Context) error {
 	devnetName := flags.DevnetNameFlag.Get(ctx)
 
-	kurtosisCtx, err := kurtosis.InitKurtosisContext()
+	enclave, err := openEnclave(ctx, devnetName)
 	if err != nil {
-		return cli.Exit(err, 1)
-	}
-	enclaveCtx, err := kurtosisCtx.GetEnclaveCtx(ctx.Context, devnetName)
-	if err != nil {
-		return cli.Exit(err.Error()+"\n\nFailed to find devnet '"+devnetName+"'. Maybe it's not running?", 1)
+		return err
 	}
-	ports, err := getServicePorts(enclaveCtx)
+	ports, err := GetServicePorts(ctx.Context, enclave)
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
@@ -34,30 +31,16 @@ func GetPorts(ctx *cli.Context) error {
 type ServicePorts map[string]string
 
 // Returns the ports exposed per service.
-func getServicePorts(enclaveCtx kurtosis.EnclaveCtx) (map[string]ServicePorts, error) {
+func GetServicePorts(ctx context.Context, enclave backend.Enclave) (map[string]ServicePorts, error) {
 	servicePorts := make(map[string]ServicePorts)
-	services, err := enclaveCtx.GetServices()
-	if err != nil {
-		return servicePorts, err
-	}
-	getServiceCtxsArgs := make(map[string]bool)
-	for _, uuid := range services {
-		getServiceCtxsArgs[string(uuid)] = true
-	}
-	serviceCtxs, err := enclaveCtx.GetServiceContexts(getServiceCtxsArgs)
+	services, err := enclave.ListServices(ctx)
 	if err != nil {
 		return servicePorts, err
 	}
-	for _, serviceCtx := range serviceCtxs {
-		ports := make(ServicePorts)
-		ipAddr := serviceCtx.GetMaybePublicIPAddress()
-		for protocolName, port := range serviceCtx.GetPublicPorts() {
-			ports[protocolName] = fmt.Sprintf("%s:%d", ipAddr, port.GetNumber())
-		}
-		name := string(serviceCtx.GetServiceName())
-		servicePorts[name] = ports
+	for _, service := range services {
+		servicePorts[service.Name] = service.Ports
 	}
-	return servicePorts, err
+	return servicePorts, nil
 }
 
 func printPorts(services map[string]ServicePorts) error {
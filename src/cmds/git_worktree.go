@@ -0,0 +1,89 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+)
+
+var ErrDirtyRef = errors.New("ref is checked out with uncommitted changes, which won't be deployed")
+
+// Checks out the given ref of the local repo into a temporary git worktree, including its submodules.
+// Fails with ErrDirtyRef if the ref is the one checked out in the repo and it has uncommitted changes,
+// unless `allowDirty` is set. Returns the worktree's path, and a function that removes it.
+func checkoutWorktree(ctx context.Context, repoPath string, ref string, allowDirty bool) (string, func(), error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", nil, &errdefs.DependencyMissingError{Dependency: "git", Err: err}
+	}
+	commit, err := runGit(ctx, repoPath, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
+	}
+	if !allowDirty {
+		var dirty bool
+		dirty, err = isCheckedOutDirty(ctx, repoPath, commit)
+		if err != nil {
+			return "", nil, err
+		}
+		if dirty {
+			return "", nil, fmt.Errorf("%w: '%s' in '%s'", ErrDirtyRef, ref, repoPath)
+		}
+	}
+
+	worktreePath, err := os.MkdirTemp(os.TempDir(), "avs-devnet-worktree-")
+	if err != nil {
+		return "", nil, fmt.Errorf("tempdir creation failed: %w", err)
+	}
+	cleanup := func() {
+		// Use a new context, since the original one may be cancelled
+		cleanupCtx := context.WithoutCancel(ctx)
+		_, _ = runGit(cleanupCtx, repoPath, "worktree", "remove", "--force", worktreePath)
+		_ = os.RemoveAll(worktreePath)
+		_, _ = runGit(cleanupCtx, repoPath, "worktree", "prune")
+	}
+	_, err = runGit(ctx, repoPath, "worktree", "add", "--detach", "--force", worktreePath, commit)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to create worktree for ref '%s': %w", ref, err)
+	}
+	_, err = runGit(ctx, worktreePath, "submodule", "update", "--init", "--recursive")
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to checkout submodules for ref '%s': %w", ref, err)
+	}
+	return worktreePath, cleanup, nil
+}
+
+// Checks if the repo has the given commit checked out, with uncommitted changes.
+func isCheckedOutDirty(ctx context.Context, repoPath string, commit string) (bool, error) {
+	head, err := runGit(ctx, repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if head != commit {
+		return false, nil
+	}
+	status, err := runGit(ctx, repoPath, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to get repo status: %w", err)
+	}
+	return status != "", nil
+}
+
+// Runs a git command inside the given dir, returning its trimmed output.
+// On failure, the error includes the command's stderr.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := newCmd(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
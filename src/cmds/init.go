@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
 	"github.com/Layr-Labs/avs-devnet/src/config"
 	"github.com/urfave/cli/v2"
 )
@@ -15,7 +16,7 @@ func InitCmd(ctx *cli.Context) error {
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
-	opts := InitOptions{configFileName}
+	opts := InitOptions{ConfigFileName: configFileName, Fast: flags.FastFlag.Get(ctx)}
 	err = Init(opts)
 	if err != nil {
 		return cli.Exit(err, 1)
@@ -26,6 +27,8 @@ func InitCmd(ctx *cli.Context) error {
 
 type InitOptions struct {
 	ConfigFileName string
+	// Use an Anvil chain instead of the ethereum-package
+	Fast bool
 }
 
 // Creates a new devnet configuration according to the config.
@@ -37,7 +40,11 @@ func Init(opts InitOptions) error {
 	if err != nil {
 		return err
 	}
-	_, err = file.WriteString(config.DefaultConfigStr())
+	contents := config.DefaultConfigStr()
+	if opts.Fast {
+		contents = config.FastConfigStr()
+	}
+	_, err = file.WriteString(contents)
 	if err != nil {
 		return err
 	}
@@ -4,12 +4,27 @@ import (
 	"testing"
 
 	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateInitialConfig(t *testing.T) {
 	tempDir := t.TempDir()
 	configFile := tempDir + "/test_devnet.yaml"
-	err := cmds.Init(cmds.InitOptions{configFile})
+	err := cmds.Init(cmds.InitOptions{ConfigFileName: configFile})
 	assert.NoError(t, err, "Failed to create new config file")
 }
+
+func TestGenerateFastConfig(t *testing.T) {
+	t.Parallel()
+	configFile := t.TempDir() + "/test_devnet.yaml"
+	err := cmds.Init(cmds.InitOptions{ConfigFileName: configFile, Fast: true})
+	require.NoError(t, err)
+
+	devnetConfig, err := config.LoadFromPath(configFile)
+	require.NoError(t, err)
+	require.Equal(t, config.ChainTypeAnvil, devnetConfig.ChainType())
+	require.Equal(t, config.DefaultConfig().Keys, devnetConfig.Keys)
+	require.Len(t, devnetConfig.Deployments, len(config.DefaultConfig().Deployments))
+}
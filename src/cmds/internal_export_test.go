@@ -0,0 +1,111 @@
+package cmds
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Layr-Labs/avs-devnet/src/config"
+)
+
+// Exposes internals of the package to its external tests.
+
+type (
+	PrepTask    = prepTask
+	Credentials = credentials
+)
+
+var (
+	RunPreparation = runPreparation
+	DownloadFile   = downloadFile
+	RedactUrl      = redactUrl
+	ExtractArchive = extractArchive
+	CreateTarGz    = createTarGz
+
+	DownloadWithRetries      = downloadWithRetries
+	IsRetryableDownloadError = isRetryableDownloadError
+	VerifyChecksum           = verifyChecksum
+	CopyGlobMatches          = copyGlobMatches
+
+	HashDockerBuild  = hashDockerBuild
+	HashCustomBuild  = hashCustomBuild
+	LoadDockerignore = loadDockerignore
+	HashDir          = hashDir
+
+	ChooseUploadMode     = chooseUploadMode
+	HasLinkedLibraries   = hasLinkedLibraries
+	FoundryProjectFilter = foundryProjectFilter
+	LoadGitignore        = loadGitignore
+	IsLibPopulated       = isLibPopulated
+	HashLocalRepo        = hashLocalRepo
+)
+
+// Returns an upload task, which runs `run` after its dependencies.
+func NewPrepTask(id string, deps []string, run func(ctx context.Context) (string, error)) PrepTask {
+	return prepTask{
+		id:   id,
+		kind: prepTaskUpload,
+		deps: deps,
+		run: func(ctx context.Context, _ chan<- buildOutputLine) (string, error) {
+			return run(ctx)
+		},
+	}
+}
+
+// Returns the error of a download failing with the given status code.
+func NewHttpStatusError(statusCode int) error {
+	return &httpStatusError{statusCode: statusCode}
+}
+
+// Returns the image names built by each job, in order.
+func CollectBuildJobs(baseDir string, services []config.Service) ([][]string, error) {
+	jobs, err := collectBuildJobs(baseDir, services)
+	imageNames := make([][]string, 0, len(jobs))
+	for _, job := range jobs {
+		imageNames = append(imageNames, job.imageNames)
+	}
+	return imageNames, err
+}
+
+// A netrc entry, with exported fields.
+type NetrcEntry struct {
+	Machine  string
+	Login    string
+	Password string
+}
+
+func LoadNetrc(path string) ([]NetrcEntry, error) {
+	entries, err := loadNetrc(path)
+	return fromNetrcEntries(entries), err
+}
+
+func LookupNetrc(entries []NetrcEntry, host string) (NetrcEntry, bool) {
+	entry, ok := lookupNetrc(toNetrcEntries(entries), host)
+	return NetrcEntry{Machine: entry.machine, Login: entry.login, Password: entry.password}, ok
+}
+
+func NewCredentials(hosts []config.HostAuth, netrc []NetrcEntry) Credentials {
+	return credentials{hosts: hosts, netrc: toNetrcEntries(netrc)}
+}
+
+func (c Credentials) AuthorizeRequest(req *http.Request) ([]string, error) {
+	return c.authorizeRequest(req)
+}
+
+func fromNetrcEntries(entries []netrcEntry) []NetrcEntry {
+	if entries == nil {
+		return nil
+	}
+	result := make([]NetrcEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, NetrcEntry{Machine: entry.machine, Login: entry.login, Password: entry.password})
+	}
+	return result
+}
+
+func toNetrcEntries(entries []NetrcEntry) []netrcEntry {
+	result := make([]netrcEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, netrcEntry{machine: entry.Machine, login: entry.Login, password: entry.Password})
+	}
+	return result
+}
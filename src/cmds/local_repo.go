@@ -0,0 +1,419 @@
+package cmds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/moby/patternmatcher"
+)
+
+// Options for uploading the local repos.
+type localRepoOptions struct {
+	// Regenerate the script artifacts even if their inputs didn't change
+	rebuild bool
+	// Allow deploying refs that are checked out with uncommitted changes
+	allowDirty bool
+	// Dir for temporary files, used when the cache isn't available
+	tempDir string
+}
+
+// Returns the preparation tasks that upload the local repositories to the enclave.
+// Deployments from the same repo are uploaded one after the other, since forge and git
+// can't safely run concurrently on the same repo.
+func localRepoTasks(
+	dirContext string,
+	devnetConfig config.DevnetConfig,
+	enclave backend.Enclave,
+	opts localRepoOptions,
+) ([]prepTask, error) {
+	tasks := make([]prepTask, 0, len(devnetConfig.Deployments))
+	lastTaskByRepo := make(map[string]string)
+	for _, deployment := range devnetConfig.Deployments {
+		if deployment.Repo == "" || !isLocalRepo(deployment.Repo) {
+			continue
+		}
+		repoUrl, err := url.Parse(deployment.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("repo '%s' is invalid: %w", deployment.Repo, err)
+		}
+		absPath := ensureAbs(dirContext, repoUrl.Path)
+		task := prepTask{
+			id:   "deployment:" + deployment.Name,
+			kind: prepTaskUpload,
+			run: func(ctx context.Context, _ chan<- buildOutputLine) (string, error) {
+				err := uploadLocalRepo(ctx, deployment, absPath, enclave, opts)
+				if err != nil {
+					return "", fmt.Errorf("local repo '%s' uploading failed: %w", absPath, err)
+				}
+				return fmt.Sprintf("Uploaded '%s'", deployment.Name), nil
+			},
+		}
+		if previousTask, ok := lastTaskByRepo[absPath]; ok {
+			task.deps = []string{previousTask}
+		}
+		lastTaskByRepo[absPath] = task.id
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// Checks if the repo is a local path, instead of a remote URL.
+func isLocalRepo(repo string) bool {
+	if isSshRepoUrl(repo) {
+		return false
+	}
+	repoUrl, err := url.Parse(repo)
+	// Invalid URLs are reported when parsing local paths
+	return err != nil || isLocalUrl(repoUrl.Scheme)
+}
+
+// Uploads the script of a single deployment from the repo at the given path to an enclave.
+// The artifact is uploaded with the deployment name suffixed with '-script', and its structure
+// is similar to the repo's structure. Depending on the deployment's upload mode, it contains either:
+//   - the flattened deployment script and the foundry config, or
+//   - the whole foundry project, for scripts that can't be safely flattened.
+//
+// If the deployment specifies a ref, the contracts are taken from a temporary worktree checked out at that ref.
+// The artifact is cached and reused while the contracts and the deployment's config don't change,
+// unless `opts.rebuild` is set. Forge is run via docker, so it doesn't need to be installed.
+func uploadLocalRepo(
+	ctx context.Context,
+	deployment config.Deployment,
+	repoPath string,
+	enclave backend.Enclave,
+	opts localRepoOptions,
+) error {
+	// Cache entries are keyed by the original repo, not the worktree
+	cacheDir, cacheErr := localRepoCachePath(repoPath, deployment)
+	if deployment.Ref != "" {
+		worktreePath, cleanup, err := checkoutWorktree(ctx, repoPath, deployment.Ref, opts.allowDirty)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		repoPath = worktreePath
+	}
+	originContractsDir := filepath.Join(repoPath, deployment.ContractsPath)
+	scriptOrigin := filepath.Join(originContractsDir, deployment.GetScriptPath())
+
+	uploadMode := deployment.GetUploadMode()
+	if !slices.Contains([]string{config.UploadModeAuto, config.UploadModeFlatten, config.UploadModeProject}, uploadMode) {
+		return fmt.Errorf("invalid upload mode '%s' for deployment '%s'", uploadMode, deployment.Name)
+	}
+
+	// Verify the script exists
+	if !fileExists(scriptOrigin) {
+		return fmt.Errorf("file '%s' doesn't exist", scriptOrigin)
+	}
+
+	// Install deps, unless they're already there
+	if !isLibPopulated(originContractsDir) {
+		output, err := runForge(ctx, originContractsDir, []string{repoPath}, "install")
+		if err != nil {
+			return fmt.Errorf("forge install failed: %w, with output: %s", err, string(output))
+		}
+	}
+
+	artifactName := deployment.Name + "-script"
+	scriptHash, err := hashLocalRepo(originContractsDir, deployment)
+	if err != nil {
+		return fmt.Errorf("hashing contracts failed: %w", err)
+	}
+	// The cache is optional, so we continue without it on errors
+	if cacheErr == nil && !opts.rebuild && isLocalRepoCached(cacheDir, scriptHash) {
+		err = enclave.UploadFiles(ctx, filepath.Join(cacheDir, "files"), artifactName)
+		if err != nil {
+			return fmt.Errorf("file uploading failed: %w", err)
+		}
+		return nil
+	}
+
+	// Output files in a temp dir, moved into the cache after upload
+	tempParentDir := opts.tempDir
+	if cacheErr == nil {
+		tempParentDir = filepath.Dir(cacheDir)
+		err = os.MkdirAll(tempParentDir, 0700)
+		if err != nil {
+			return fmt.Errorf("cache dir creation failed: %w", err)
+		}
+	}
+	tempDir, err := os.MkdirTemp(tempParentDir, "avs-devnet-")
+	if err != nil {
+		return fmt.Errorf("tempdir creation failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	outputDir := filepath.Join(tempDir, "files")
+	err = prepareScriptArtifact(ctx, deployment, repoPath, outputDir)
+	if err != nil {
+		return err
+	}
+
+	// Upload the file to the enclave
+	err = enclave.UploadFiles(ctx, outputDir, artifactName)
+	if err != nil {
+		return fmt.Errorf("file uploading failed: %w", err)
+	}
+
+	if cacheErr == nil {
+		storeLocalRepoCache(tempDir, cacheDir, scriptHash)
+	}
+	return nil
+}
+
+// Writes the files of a deployment's script artifact to `outputDir`.
+// See uploadLocalRepo for details.
+func prepareScriptArtifact(ctx context.Context, deployment config.Deployment, repoPath string, outputDir string) error {
+	scriptPath := deployment.GetScriptPath()
+	originContractsDir := filepath.Join(repoPath, deployment.ContractsPath)
+	scriptOrigin := filepath.Join(originContractsDir, scriptPath)
+	destinationContractsDir := filepath.Join(outputDir, deployment.ContractsPath)
+	scriptDestination := filepath.Join(destinationContractsDir, scriptPath)
+
+	err := os.MkdirAll(filepath.Dir(scriptDestination), 0700)
+	if err != nil {
+		return fmt.Errorf("output dir creation failed: %w", err)
+	}
+
+	uploadMode := deployment.GetUploadMode()
+	if uploadMode != config.UploadModeProject {
+		// Flatten the script into a single file before upload
+		mountDirs := []string{repoPath, outputDir}
+		var output []byte
+		output, err = runForge(ctx, originContractsDir, mountDirs, "flatten", "-o", scriptDestination, scriptOrigin)
+		if err != nil {
+			return fmt.Errorf("script flattening failed: %w, with output: %s", err, string(output))
+		}
+	}
+	if uploadMode == config.UploadModeAuto {
+		uploadMode, err = chooseUploadMode(scriptDestination)
+		if err != nil {
+			return err
+		}
+	}
+
+	if uploadMode == config.UploadModeProject {
+		err = copyFoundryProject(originContractsDir, destinationContractsDir)
+		if err != nil {
+			return fmt.Errorf("failed when copying foundry project: %w", err)
+		}
+	} else {
+		// Copy the foundry config inside the contracts dir
+		src := filepath.Join(originContractsDir, "foundry.toml")
+		dst := filepath.Join(destinationContractsDir, "foundry.toml")
+		err = fileCopy(src, dst)
+		if err != nil {
+			return fmt.Errorf("failed when copying foundry.toml: %w", err)
+		}
+	}
+	return nil
+}
+
+// Checks if the foundry project's dependencies are already installed.
+// That is, the lib dir has at least one dependency, and none of them is an uninitialized submodule.
+func isLibPopulated(contractsDir string) bool {
+	libDir := filepath.Join(contractsDir, "lib")
+	entries, err := os.ReadDir(libDir)
+	if err != nil || len(entries) == 0 {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		var depEntries []os.DirEntry
+		depEntries, err = os.ReadDir(filepath.Join(libDir, entry.Name()))
+		if err != nil || len(depEntries) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a hash of the inputs of a deployment's script artifact.
+// These are the foundry project's files (the same ones uploaded in project mode), and the deployment's script config.
+func hashLocalRepo(contractsDir string, deployment config.Deployment) (string, error) {
+	include, err := foundryProjectFilter(contractsDir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	writeHashField(h, buildHashVersion)
+	writeHashField(h, foundryImage)
+	writeHashField(h, deployment.ContractsPath)
+	writeHashField(h, deployment.GetScriptPath())
+	writeHashField(h, deployment.GetUploadMode())
+	err = hashDir(h, contractsDir, include)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Returns the path of the dir caching a deployment's script artifact.
+// Each deployment of each repo has a single cache entry, which is replaced when its inputs change.
+func localRepoCachePath(repoPath string, deployment config.Deployment) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	writeHashField(h, repoPath)
+	writeHashField(h, deployment.Name)
+	dirName := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(cacheDir, "avs-devnet", "scripts", dirName), nil
+}
+
+// Checks if the cached script artifact was generated from inputs with the given hash.
+func isLocalRepoCached(cacheDir string, scriptHash string) bool {
+	contents, err := os.ReadFile(filepath.Join(cacheDir, "hash"))
+	return err == nil && string(contents) == scriptHash && fileExists(filepath.Join(cacheDir, "files"))
+}
+
+// Moves the generated script artifact in `tempDir` into the cache, replacing any previous entry.
+// Errors are ignored, since the cache is optional.
+func storeLocalRepoCache(tempDir string, cacheDir string, scriptHash string) {
+	err := os.WriteFile(filepath.Join(tempDir, "hash"), []byte(scriptHash), 0600)
+	if err != nil {
+		return
+	}
+	_ = os.RemoveAll(cacheDir)
+	_ = os.Rename(tempDir, cacheDir)
+}
+
+// Patterns in flattened scripts that indicate the script depends on other files of the project.
+//
+//nolint:gochecknoglobals // these are constants
+var (
+	// Cheatcodes that access the filesystem or the compilation artifacts
+	fileAccessRegex = regexp.MustCompile(
+		`\bvm\.(readFile|readFileBinary|readLine|readDir|writeFile|projectRoot|exists|isFile|isDir|` +
+			`fsMetadata|getCode|getDeployedCode|deployCode|ffi)\b`,
+	)
+	// Script contracts
+	scriptContractRegex = regexp.MustCompile(`\bcontract\s+\w+\s+is\s+[^{]*\bScript\b`)
+	// Start of a library
+	libraryRegex = regexp.MustCompile(`\blibrary\s+\w+\s*\{`)
+	// Functions that require the library to be linked
+	linkedFunctionRegex = regexp.MustCompile(`\bfunction\b[^{;]*\b(public|external)\b`)
+)
+
+// Decides the upload mode for a flattened script, based on its contents.
+// Returns UploadModeProject if the flattened script may not work on its own.
+func chooseUploadMode(flattenedScriptPath string) (string, error) {
+	contents, err := os.ReadFile(flattenedScriptPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read flattened script: %w", err)
+	}
+	source := string(contents)
+	if fileAccessRegex.MatchString(source) ||
+		len(scriptContractRegex.FindAllStringIndex(source, -1)) > 1 ||
+		hasLinkedLibraries(source) {
+		return config.UploadModeProject, nil
+	}
+	return config.UploadModeFlatten, nil
+}
+
+// Checks if any library in the source has public or external functions, which need linking.
+func hasLinkedLibraries(source string) bool {
+	for _, loc := range libraryRegex.FindAllStringIndex(source, -1) {
+		body := source[loc[1]:]
+		// Find the end of the library's body
+		depth := 1
+		for i, c := range body {
+			switch c {
+			case '{':
+				depth += 1
+			case '}':
+				depth -= 1
+			}
+			if depth == 0 {
+				body = body[:i]
+				break
+			}
+		}
+		if linkedFunctionRegex.MatchString(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dirs inside the foundry project that are never uploaded.
+//
+//nolint:gochecknoglobals // this is a constant
+var excludedProjectDirs = []string{"out", "cache"}
+
+// Returns a filter for the foundry project's files, in the format expected by hashDir.
+// Build outputs, git metadata, and files ignored by the project's .gitignore are excluded.
+func foundryProjectFilter(projectDir string) (func(relPath string, isDir bool) bool, error) {
+	matcher, err := loadGitignore(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return func(relPath string, isDir bool) bool {
+		if path.Base(relPath) == ".git" || (isDir && slices.Contains(excludedProjectDirs, relPath)) {
+			return false
+		}
+		ignored, _ := matcher.MatchesOrParentMatches(relPath)
+		return !ignored
+	}, nil
+}
+
+// Copies the foundry project's files from `srcDir` to `dstDir`.
+// Build outputs, git metadata, and files ignored by the project's .gitignore are skipped.
+func copyFoundryProject(srcDir, dstDir string) error {
+	include, err := foundryProjectFilter(srcDir)
+	if err != nil {
+		return err
+	}
+	return fsutil.CopyDir(srcDir, dstDir, include)
+}
+
+// Returns a pattern matcher for the .gitignore file at the root of the given dir.
+// Nested .gitignore files are not supported.
+func loadGitignore(dir string) (*patternmatcher.PatternMatcher, error) {
+	contents, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return patternmatcher.New(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	patterns := make([]string, 0)
+	for _, line := range strings.Split(string(contents), "\n") {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		// NOTE: we don't differentiate between dirs and files
+		pattern = strings.TrimSuffix(pattern, "/")
+		if strings.Contains(pattern, "/") {
+			// Patterns with a slash are relative to the .gitignore's dir
+			pattern = strings.TrimPrefix(pattern, "/")
+		} else {
+			// Other patterns match at any level
+			pattern = "**/" + pattern
+		}
+		if negated {
+			pattern = "!" + pattern
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patternmatcher.New(patterns)
+}
@@ -0,0 +1,371 @@
+package cmds_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+)
+
+const flattenedScript = `
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.12;
+
+library Math {
+    function max(uint256 a, uint256 b) internal pure returns (uint256) {
+        if (a > b) {
+            return a;
+        }
+        return b;
+    }
+}
+
+contract Deploy is Script {
+    function run() public {
+        vm.startBroadcast();
+    }
+}
+`
+
+func TestChooseUploadMode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "self-contained script", source: flattenedScript, expected: config.UploadModeFlatten},
+		{
+			name:     "reads files",
+			source:   flattenedScript + `contract Reader { function f() public { vm.readFile("config.json"); } }`,
+			expected: config.UploadModeProject,
+		},
+		{
+			name:     "uses the project root",
+			source:   flattenedScript + `contract Root { string root = vm.projectRoot(); }`,
+			expected: config.UploadModeProject,
+		},
+		{
+			name:     "deploys compiled artifacts",
+			source:   flattenedScript + `contract Deployer { function f() public { vm.deployCode("Token.sol"); } }`,
+			expected: config.UploadModeProject,
+		},
+		{
+			name:     "multiple scripts",
+			source:   flattenedScript + "contract Upgrade is Script, Utils {\n    function run() public {}\n}\n",
+			expected: config.UploadModeProject,
+		},
+		{
+			name:     "linked library",
+			source:   flattenedScript + "library Linked {\n    function f() public pure {}\n}\n",
+			expected: config.UploadModeProject,
+		},
+		{
+			name:     "cheatcode name in another identifier",
+			source:   flattenedScript + `contract Other { function readFileName() public {} }`,
+			expected: config.UploadModeFlatten,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			scriptPath := filepath.Join(t.TempDir(), "Deploy.s.sol")
+			require.NoError(t, os.WriteFile(scriptPath, []byte(tc.source), 0600))
+			mode, err := cmds.ChooseUploadMode(scriptPath)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, mode)
+		})
+	}
+}
+
+func TestChooseUploadModeMissingScript(t *testing.T) {
+	t.Parallel()
+	_, err := cmds.ChooseUploadMode(filepath.Join(t.TempDir(), "Deploy.s.sol"))
+	require.ErrorContains(t, err, "failed to read flattened script")
+}
+
+func TestHasLinkedLibraries(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{name: "no libraries", source: "contract C {\n    function f() public {}\n}", expected: false},
+		{
+			name:     "internal functions",
+			source:   "library L {\n    function f() internal pure returns (uint256) { return 1; }\n}",
+			expected: false,
+		},
+		{
+			name:     "private functions",
+			source:   "library L {\n    function f() private pure {}\n}",
+			expected: false,
+		},
+		{name: "public function", source: "library L {\n    function f() public {}\n}", expected: true},
+		{name: "external function", source: "library L {\n    function f(uint256 a) external view {}\n}", expected: true},
+		{
+			name: "public function after nested blocks",
+			source: "library L {\n    struct S { uint256 a; }\n" +
+				"    function f() internal { if (true) {} }\n    function g() public {}\n}",
+			expected: true,
+		},
+		{
+			name:     "public function outside the library",
+			source:   "library L {\n    function f() internal {}\n}\ncontract C {\n    function g() public {}\n}",
+			expected: false,
+		},
+		{
+			name:     "second library",
+			source:   "library A {\n    function f() internal {}\n}\nlibrary B {\n    function g() external {}\n}",
+			expected: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, cmds.HasLinkedLibraries(tc.source))
+		})
+	}
+}
+
+func TestFoundryProjectFilter(t *testing.T) {
+	t.Parallel()
+	projectDir := t.TempDir()
+	gitignore := "# Build outputs\nnode_modules/\n*.log\n!keep.log\n/broadcast\nscript/local/\n"
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte(gitignore), 0600))
+	include, err := cmds.FoundryProjectFilter(projectDir)
+	require.NoError(t, err)
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{path: "src/Contract.sol", expected: true},
+		{path: "foundry.toml", expected: true},
+		{path: ".gitignore", expected: true},
+		{path: "lib/forge-std", isDir: true, expected: true},
+		{path: ".git", isDir: true, expected: false},
+		// Submodules have a .git file
+		{path: "lib/forge-std/.git", expected: false},
+		{path: "out", isDir: true, expected: false},
+		{path: "cache", isDir: true, expected: false},
+		// Only the project's top-level build outputs are excluded
+		{path: "src/out", isDir: true, expected: true},
+		{path: "node_modules", isDir: true, expected: false},
+		{path: "lib/dep/node_modules", isDir: true, expected: false},
+		{path: "debug.log", expected: false},
+		{path: "logs/debug.log", expected: false},
+		{path: "keep.log", expected: true},
+		{path: "broadcast", isDir: true, expected: false},
+		{path: "script/broadcast", isDir: true, expected: true},
+		{path: "script/local", isDir: true, expected: false},
+		{path: "script/Deploy.s.sol", expected: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, include(tc.path, tc.isDir))
+		})
+	}
+}
+
+func TestLoadGitignore(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		gitignore *string
+		// Whether each path is ignored
+		ignored map[string]bool
+	}{
+		{
+			name:    "no gitignore",
+			ignored: map[string]bool{"out/Contract.json": false, "debug.log": false},
+		},
+		{
+			name:      "comments and blank lines",
+			gitignore: ptr("# *.log\n\n   \n"),
+			ignored:   map[string]bool{"debug.log": false},
+		},
+		{
+			name:      "patterns match at any level",
+			gitignore: ptr("*.log\nsecrets\n"),
+			ignored: map[string]bool{
+				"debug.log":            true,
+				"nested/dir/debug.log": true,
+				"secrets":              true,
+				"config/secrets/key":   true,
+				"README.md":            false,
+			},
+		},
+		{
+			name:      "patterns with slashes are anchored",
+			gitignore: ptr("/out/\ndocs/build\n"),
+			ignored: map[string]bool{
+				"out/Contract.json":   true,
+				"lib/out/Lib.json":    false,
+				"docs/build/index":    true,
+				"lib/docs/build/file": false,
+			},
+		},
+		{
+			name:      "negated patterns",
+			gitignore: ptr("*.env\n!example.env\n"),
+			ignored:   map[string]bool{".env": true, "prod.env": true, "example.env": false},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			if tc.gitignore != nil {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(*tc.gitignore), 0600))
+			}
+			matcher, err := cmds.LoadGitignore(dir)
+			require.NoError(t, err)
+			for path, expected := range tc.ignored {
+				ignored, err := matcher.MatchesOrParentMatches(path)
+				require.NoError(t, err)
+				require.Equal(t, expected, ignored, "path '%s'", path)
+			}
+		})
+	}
+}
+
+func TestIsLibPopulated(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// Files inside the contracts dir, by slash-separated path. Dirs end with a slash.
+		files    []string
+		expected bool
+	}{
+		{name: "missing lib dir", files: []string{"foundry.toml"}, expected: false},
+		{name: "empty lib dir", files: []string{"lib/"}, expected: false},
+		{name: "populated", files: []string{"lib/forge-std/src/Test.sol"}, expected: true},
+		{
+			name:     "multiple dependencies",
+			files:    []string{"lib/forge-std/src/Test.sol", "lib/openzeppelin/README.md"},
+			expected: true,
+		},
+		{
+			name:     "uninitialized submodule",
+			files:    []string{"lib/forge-std/src/Test.sol", "lib/openzeppelin/"},
+			expected: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for _, file := range tc.files {
+				filePath := filepath.Join(dir, filepath.FromSlash(file))
+				if strings.HasSuffix(file, "/") {
+					require.NoError(t, os.MkdirAll(filePath, 0700))
+					continue
+				}
+				writeTestFiles(t, dir, map[string]string{file: "contents"})
+			}
+			require.Equal(t, tc.expected, cmds.IsLibPopulated(dir))
+		})
+	}
+}
+
+func TestHashLocalRepo(t *testing.T) {
+	t.Parallel()
+	deployment := config.Deployment{Name: "avs", ContractsPath: "contracts", Script: "script/Deploy.s.sol"}
+	tests := []struct {
+		name       string
+		files      map[string]string
+		deployment config.Deployment
+		changed    bool
+	}{
+		{name: "no changes", deployment: deployment},
+		{
+			name:       "script",
+			files:      map[string]string{"script/Deploy.s.sol": "contract Deploy is Script { /* changed */ }"},
+			deployment: deployment,
+			changed:    true,
+		},
+		{
+			name:       "new script",
+			files:      map[string]string{"script/Upgrade.s.sol": "contract Upgrade is Script {}"},
+			deployment: deployment,
+			changed:    true,
+		},
+		{
+			name:       "contract",
+			files:      map[string]string{"src/Registry.sol": "contract Registry { uint256 x; }"},
+			deployment: deployment,
+			changed:    true,
+		},
+		{
+			name:       "dependency",
+			files:      map[string]string{"lib/forge-std/src/Test.sol": "contract Test { /* updated */ }"},
+			deployment: deployment,
+			changed:    true,
+		},
+		{
+			name: "script path",
+			deployment: config.Deployment{
+				Name: "avs", ContractsPath: "contracts", Script: "script/Upgrade.s.sol",
+			},
+			changed: true,
+		},
+		{
+			name: "upload mode",
+			deployment: config.Deployment{
+				Name: "avs", ContractsPath: "contracts", Script: "script/Deploy.s.sol", UploadMode: config.UploadModeProject,
+			},
+			changed: true,
+		},
+		{
+			name:       "build outputs",
+			files:      map[string]string{"out/Deploy.s.sol/Deploy.json": "{}", "cache/solidity-files-cache.json": "{}"},
+			deployment: deployment,
+		},
+		{
+			name:       "ignored files",
+			files:      map[string]string{"broadcast/run-latest.json": "{}", ".env": "KEY=value"},
+			deployment: deployment,
+		},
+		{
+			name:       "git metadata",
+			files:      map[string]string{".git/HEAD": "ref: refs/heads/other"},
+			deployment: deployment,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeTestFiles(t, dir, map[string]string{
+				".gitignore":                 "broadcast/\n.env\n",
+				"foundry.toml":               "[profile.default]\nsrc = 'src'\n",
+				"script/Deploy.s.sol":        "contract Deploy is Script {}",
+				"src/Registry.sol":           "contract Registry {}",
+				"lib/forge-std/src/Test.sol": "contract Test {}",
+				".git/HEAD":                  "ref: refs/heads/main",
+			})
+			hash, err := cmds.HashLocalRepo(dir, deployment)
+			require.NoError(t, err)
+			// Stable for the same inputs
+			sameHash, err := cmds.HashLocalRepo(dir, deployment)
+			require.NoError(t, err)
+			require.Equal(t, hash, sameHash)
+
+			writeTestFiles(t, dir, tc.files)
+			newHash, err := cmds.HashLocalRepo(dir, tc.deployment)
+			require.NoError(t, err)
+			if tc.changed {
+				require.NotEqual(t, hash, newHash)
+			} else {
+				require.Equal(t, hash, newHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+)
+
+// The kind of a preparation task, which decides how its progress is reported.
+type prepTaskKind int
+
+const (
+	prepTaskBuild prepTaskKind = iota
+	prepTaskUpload
+	prepTaskDownload
+)
+
+// A unit of work done before running the Kurtosis package.
+type prepTask struct {
+	// Unique identifier of the task
+	id   string
+	kind prepTaskKind
+	// IDs of the tasks that must finish successfully before this one starts
+	deps []string
+	// Runs the task, returning a description of the result.
+	// Only build tasks send lines to `outputChan`.
+	run func(ctx context.Context, outputChan chan<- buildOutputLine) (string, error)
+}
+
+// The result of a preparation task.
+type prepResult struct {
+	taskIndex   int
+	description string
+	err         error
+}
+
+// Runs the preparation tasks, with up to `maxJobs` running at the same time.
+// Tasks start as soon as their dependencies finish, and are skipped if any of them fails.
+// Returns the errors of all the failed tasks.
+func runPreparation(
+	ctx context.Context,
+	reporter progress_reporters.Reporter,
+	tasks []prepTask,
+	maxJobs int,
+) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	taskIndexById := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if _, ok := taskIndexById[task.id]; ok {
+			return fmt.Errorf("duplicate preparation task '%s'", task.id)
+		}
+		taskIndexById[task.id] = i
+	}
+	pendingDeps := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	ready := make([]int, 0, len(tasks))
+	for i, task := range tasks {
+		for _, dep := range task.deps {
+			depIndex, ok := taskIndexById[dep]
+			if !ok {
+				return fmt.Errorf("preparation task '%s' depends on unknown task '%s'", task.id, dep)
+			}
+			dependents[depIndex] = append(dependents[depIndex], i)
+			pendingDeps[i] += 1
+		}
+		if pendingDeps[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	resultChan := make(chan prepResult)
+	outputChan := make(chan buildOutputLine)
+	skipped := make([]bool, len(tasks))
+	// Marks the task and its dependents as skipped, returning the number of newly skipped tasks
+	var skip func(i int) int
+	skip = func(i int) int {
+		if skipped[i] {
+			return 0
+		}
+		skipped[i] = true
+		count := 1
+		for _, dependent := range dependents[i] {
+			count += skip(dependent)
+		}
+		return count
+	}
+
+	// Reporter calls are done from this goroutine only
+	reportErr := reporter.ReportPreparationStart(len(tasks))
+	errs := make([]error, 0)
+	running := 0
+	finished := 0
+	for finished < len(tasks) {
+		for running < maxJobs && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			if ctx.Err() != nil {
+				// Don't start new tasks after being cancelled
+				finished += skip(i)
+				continue
+			}
+			running += 1
+			go func() {
+				description, err := tasks[i].run(ctx, outputChan)
+				resultChan <- prepResult{i, description, err}
+			}()
+		}
+		if running == 0 {
+			if len(ready) == 0 && finished < len(tasks) {
+				return errors.New("preparation tasks have a dependency cycle")
+			}
+			continue
+		}
+		select {
+		case output := <-outputChan:
+			if reportErr == nil {
+				reportErr = reporter.ReportBuildOutput(output.jobName, output.line)
+			}
+		case result := <-resultChan:
+			running -= 1
+			finished += 1
+			if result.err != nil {
+				errs = append(errs, result.err)
+				// The task counts as finished already
+				finished += skip(result.taskIndex) - 1
+				break
+			}
+			for _, dependent := range dependents[result.taskIndex] {
+				pendingDeps[dependent] -= 1
+				if pendingDeps[dependent] == 0 && !skipped[dependent] {
+					ready = append(ready, dependent)
+				}
+			}
+			if reportErr == nil {
+				reportErr = reportPrepStep(reporter, tasks[result.taskIndex].kind, progress_reporters.PreparationStep{
+					CurrentStep: finished,
+					TotalSteps:  len(tasks),
+					Description: result.description,
+				})
+			}
+		}
+	}
+	if len(errs) == 0 && ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+	return errors.Join(append(errs, reportErr)...)
+}
+
+// Reports a finished preparation step, according to the task's kind.
+func reportPrepStep(
+	reporter progress_reporters.Reporter,
+	kind prepTaskKind,
+	stepInfo progress_reporters.PreparationStep,
+) error {
+	switch kind {
+	case prepTaskBuild:
+		return reporter.ReportBuildStep(stepInfo)
+	case prepTaskUpload:
+		return reporter.ReportUploadStep(stepInfo)
+	default:
+		return reporter.ReportDownloadStep(stepInfo)
+	}
+}
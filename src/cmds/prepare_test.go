@@ -0,0 +1,130 @@
+package cmds_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+	"github.com/stretchr/testify/require"
+)
+
+// Records the tasks run by the preparation, in order.
+type taskRecorder struct {
+	mu  sync.Mutex
+	ran []string
+	// Number of tasks running right now, and the maximum seen
+	running    int
+	maxRunning int
+}
+
+// Returns a task that records its run, and fails with `err` if not nil.
+func (r *taskRecorder) task(id string, err error, deps ...string) cmds.PrepTask {
+	return cmds.NewPrepTask(id, deps, func(context.Context) (string, error) {
+		r.mu.Lock()
+		r.ran = append(r.ran, id)
+		r.running += 1
+		r.maxRunning = max(r.maxRunning, r.running)
+		r.mu.Unlock()
+		defer func() {
+			r.mu.Lock()
+			r.running -= 1
+			r.mu.Unlock()
+		}()
+		return "Ran " + id, err
+	})
+}
+
+func runTasks(ctx context.Context, maxJobs int, tasks ...cmds.PrepTask) error {
+	reporter := progress_reporters.NewProgressBarReporter(false)
+	return cmds.RunPreparation(ctx, reporter, tasks, maxJobs)
+}
+
+func TestRunPreparationOrdersDependencies(t *testing.T) {
+	t.Parallel()
+	recorder := &taskRecorder{}
+	err := runTasks(context.Background(), 4,
+		recorder.task("upload", nil, "build", "download"),
+		recorder.task("build", nil, "download"),
+		recorder.task("download", nil),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"download", "build", "upload"}, recorder.ran)
+}
+
+func TestRunPreparationLimitsJobs(t *testing.T) {
+	t.Parallel()
+	recorder := &taskRecorder{}
+	tasks := make([]cmds.PrepTask, 0, 10)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		tasks = append(tasks, recorder.task(id, nil))
+	}
+	err := runTasks(context.Background(), 2, tasks...)
+	require.NoError(t, err)
+	require.Len(t, recorder.ran, 10)
+	require.LessOrEqual(t, recorder.maxRunning, 2)
+}
+
+func TestRunPreparationSkipsDependents(t *testing.T) {
+	t.Parallel()
+	recorder := &taskRecorder{}
+	failure := errors.New("build failed")
+	err := runTasks(context.Background(), 1,
+		recorder.task("build", failure),
+		recorder.task("push", nil, "build"),
+		recorder.task("deploy", nil, "push"),
+		recorder.task("download", nil),
+	)
+	require.ErrorIs(t, err, failure)
+	// Failures don't stop the independent tasks
+	require.ElementsMatch(t, []string{"build", "download"}, recorder.ran)
+}
+
+func TestRunPreparationStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &taskRecorder{}
+	cancelling := cmds.NewPrepTask("cancel", nil, func(context.Context) (string, error) {
+		cancel()
+		return "Cancelled", nil
+	})
+	err := runTasks(ctx, 1, cancelling, recorder.task("next", nil), recorder.task("dependent", nil, "cancel"))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, recorder.ran)
+}
+
+func TestRunPreparationInvalidTasks(t *testing.T) {
+	t.Parallel()
+	recorder := &taskRecorder{}
+	tests := []struct {
+		name        string
+		tasks       []cmds.PrepTask
+		expectedErr string
+	}{
+		{
+			name:        "cycle",
+			tasks:       []cmds.PrepTask{recorder.task("a", nil, "b"), recorder.task("b", nil, "a")},
+			expectedErr: "dependency cycle",
+		},
+		{
+			name:        "unknown dependency",
+			tasks:       []cmds.PrepTask{recorder.task("a", nil, "missing")},
+			expectedErr: "depends on unknown task 'missing'",
+		},
+		{
+			name:        "duplicate",
+			tasks:       []cmds.PrepTask{recorder.task("a", nil), recorder.task("a", nil)},
+			expectedErr: "duplicate preparation task 'a'",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := runTasks(context.Background(), 2, tc.tasks...)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
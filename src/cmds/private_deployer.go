@@ -0,0 +1,171 @@
+package cmds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/kurtosis_package/dockerfiles"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+)
+
+var ErrMissingKnownHosts = errors.New("no ~/.ssh/known_hosts file to check the repo host's key against")
+
+// Name of the images built from private repos.
+const privateDeployerImage = "avs-devnet-private-deployer"
+
+// Returns the preparation tasks that build the deployer images of deployments from private repos.
+// A repo is considered private if it's cloned over SSH, or there are credentials for its host.
+// Public repos are built by Kurtosis instead.
+// Also returns the image built for each deployment, by deployment name.
+func privateDeployerTasks(
+	devnetConfig config.DevnetConfig,
+	creds credentials,
+	tempDir string,
+	rebuild bool,
+) ([]prepTask, map[string]string, error) {
+	tasks := make([]prepTask, 0)
+	imagesByDeployment := make(map[string]string)
+	for _, deployment := range devnetConfig.Deployments {
+		repo := deployment.Repo
+		if repo == "" || deployment.DeployerImage != "" || isLocalRepo(repo) {
+			continue
+		}
+		if !isSshRepoUrl(repo) && !creds.hasCredentials(repoHost(repo)) {
+			continue
+		}
+		if deployment.Name == "" {
+			return nil, nil, fmt.Errorf("deployments from private repo '%s' must have a name", redactUrl(repo))
+		}
+		contractsPath := deployment.ContractsPath
+		if contractsPath == "" {
+			contractsPath = "."
+		}
+		imageName := privateDeployerImageName(repo, deployment.Ref, contractsPath)
+		imagesByDeployment[deployment.Name] = imageName
+		tasks = append(tasks, prepTask{
+			id:   "deployer:" + deployment.Name,
+			kind: prepTaskBuild,
+			run: func(ctx context.Context, outputChan chan<- buildOutputLine) (string, error) {
+				err := buildPrivateDeployer(ctx, outputChan, deployment, contractsPath, imageName, creds, tempDir, rebuild)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Built deployer for '%s'", deployment.Name), nil
+			},
+		})
+	}
+	return tasks, imagesByDeployment, nil
+}
+
+// Returns the name of the deployer image for the given repo, ref, and contracts path.
+func privateDeployerImageName(repo string, ref string, contractsPath string) string {
+	h := sha256.New()
+	writeHashField(h, repo)
+	writeHashField(h, ref)
+	writeHashField(h, contractsPath)
+	return privateDeployerImage + ":" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Builds the deployer image of a deployment from a private repo.
+// Credentials are passed as BuildKit secrets, and SSH keys via agent forwarding,
+// so they aren't stored in the image, nor shown in the build output.
+func buildPrivateDeployer(
+	ctx context.Context,
+	outputChan chan<- buildOutputLine,
+	deployment config.Deployment,
+	contractsPath string,
+	imageName string,
+	creds credentials,
+	tempDir string,
+	rebuild bool,
+) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	repo := deployment.Repo
+	if deployment.Ref == "" {
+		return fmt.Errorf("deployment '%s' from a remote repo must specify a ref", deployment.Name)
+	}
+	secretsDir, err := os.MkdirTemp(tempDir, "secrets-")
+	if err != nil {
+		return fmt.Errorf("tempdir creation failed: %w", err)
+	}
+	defer os.RemoveAll(secretsDir)
+
+	cmdArgs := []string{
+		"build", "-t", imageName,
+		"--target", "private_deployer",
+		"--build-arg", "CONTRACTS_REPO=" + repo,
+		"--build-arg", "CONTRACTS_REF=" + deployment.Ref,
+		"--build-arg", "CONTRACTS_PATH=" + contractsPath,
+	}
+	if rebuild {
+		cmdArgs = append(cmdArgs, "--no-cache")
+	}
+	// Submodules may be cloned over SSH too, so the known hosts are passed for any repo
+	knownHostsPath := userKnownHostsPath()
+	if knownHostsPath != "" {
+		cmdArgs = append(cmdArgs, "--secret", "id=known_hosts,src="+knownHostsPath)
+	}
+	if isSshRepoUrl(repo) {
+		if os.Getenv("SSH_AUTH_SOCK") == "" {
+			return &errdefs.DependencyMissingError{
+				Dependency: "ssh-agent",
+				Err:        fmt.Errorf("cloning '%s' over SSH requires a running SSH agent", deployment.Name),
+			}
+		}
+		if knownHostsPath == "" {
+			host := repoHost(repo)
+			return fmt.Errorf(
+				"%w. Add the key of '%s' with `ssh-keyscan %s >> ~/.ssh/known_hosts`, after checking its fingerprint",
+				ErrMissingKnownHosts, host, host,
+			)
+		}
+		cmdArgs = append(cmdArgs, "--ssh", "default")
+	} else {
+		var gitCredentials string
+		gitCredentials, err = creds.gitCredentialsFile(repoHost(repo))
+		if err != nil {
+			return err
+		}
+		credentialsPath := filepath.Join(secretsDir, "git_credentials")
+		err = os.WriteFile(credentialsPath, []byte(gitCredentials), 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write git credentials: %w", err)
+		}
+		cmdArgs = append(cmdArgs, "--secret", "id=git_credentials,src="+credentialsPath)
+	}
+	// The Dockerfile is read from stdin, without a build context
+	cmdArgs = append(cmdArgs, "-")
+
+	cmd := newCmd(ctx, "docker", cmdArgs...)
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	cmd.Stdin = strings.NewReader(dockerfiles.ContractDeployer)
+	jobName := "deployer:" + deployment.Name
+	output, err := runStreamingOutput(cmd, outputChan, jobName)
+	if err != nil {
+		return fmt.Errorf("building deployer for '%s' failed: %w\n%s", deployment.Name, err, output)
+	}
+	return nil
+}
+
+// Returns the path of the user's known_hosts file, or an empty string if there's none.
+func userKnownHostsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	knownHostsPath := filepath.Join(homeDir, ".ssh", "known_hosts")
+	if !fileExists(knownHostsPath) {
+		return ""
+	}
+	return knownHostsPath
+}
@@ -0,0 +1,247 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var ErrSnapshotChainType = errors.New("snapshots can only be restored on an 'anvil' chain")
+
+// Version of the snapshot format, increased on incompatible changes.
+const snapshotVersion = 1
+
+// Paths inside a snapshot.
+const (
+	snapshotManifestFile = "snapshot.json"
+	snapshotChainDir     = "chain"
+	snapshotArtifacts    = "artifacts"
+)
+
+// Artifacts used by the Kurtosis package to save and restore snapshots.
+const (
+	// Keys and addresses generated by the devnet. See kurtosis_package/snapshot.star
+	snapshotDataArtifact = "devnet_data"
+	snapshotDataFile     = "data.json"
+	// Chain state loaded by the restored devnet's Anvil node. See kurtosis_package/anvil.star
+	snapshotStateArtifact = "devnet_chain_state"
+	snapshotStateFile     = "chain-state.json"
+)
+
+// Describes the contents of a snapshot.
+type snapshotManifest struct {
+	Version int `json:"version"`
+	// Names of the artifacts stored in the snapshot
+	Artifacts []string `json:"artifacts"`
+}
+
+// Saves a snapshot of the running devnet.
+func SnapshotSaveCmd(ctx *cli.Context) error {
+	devnetName := flags.DevnetNameFlag.Get(ctx)
+	args := ctx.Args()
+	if args.Len() != 1 {
+		return cli.Exit("expected 1 argument: <snapshot-file>", 1)
+	}
+	snapshotPath := args.First()
+	enclave, err := openEnclave(ctx, devnetName)
+	if err != nil {
+		return err
+	}
+	err = SaveSnapshot(ctx.Context, enclave, snapshotPath)
+	if err != nil {
+		return toExitError(err, devnetName)
+	}
+	fmt.Printf("Snapshot of devnet '%s' saved to '%s'. Restore it with:\n\n", devnetName, snapshotPath)
+	fmt.Printf("  avs-devnet start --from-snapshot %s\n", snapshotPath)
+	return nil
+}
+
+// Writes a snapshot of the devnet to `snapshotPath`, as a gzipped tarball.
+// It holds the chain's state, along with the devnet's artifacts and generated keys and addresses.
+// The chain must be an Anvil node, since the state is dumped with `anvil_dumpState`.
+func SaveSnapshot(ctx context.Context, enclave backend.Enclave, snapshotPath string) error {
+	services, err := enclave.ListServices(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	artifactNames, err := enclave.ListArtifacts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	manifest := snapshotManifest{Version: snapshotVersion, Artifacts: make([]string, 0, len(artifactNames))}
+	for _, artifactName := range artifactNames {
+		if isSnapshotArtifact(artifactName) {
+			manifest.Artifacts = append(manifest.Artifacts, artifactName)
+		}
+	}
+	if !slices.Contains(manifest.Artifacts, snapshotDataArtifact) {
+		return fmt.Errorf("the devnet has no '%s' artifact. Was it started with an older version?", snapshotDataArtifact)
+	}
+
+	tempDir, err := os.MkdirTemp(os.TempDir(), "avs-devnet-snapshot-")
+	if err != nil {
+		return fmt.Errorf("tempdir creation failed: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	state, err := dumpDevnetChainState(ctx, services)
+	if err != nil {
+		return err
+	}
+	chainDir := filepath.Join(tempDir, snapshotChainDir)
+	if err = os.MkdirAll(chainDir, 0700); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(chainDir, snapshotStateFile), state, 0600); err != nil {
+		return err
+	}
+	for _, artifactName := range manifest.Artifacts {
+		if err = checkArtifactName(artifactName); err != nil {
+			return err
+		}
+		dstDir := filepath.Join(tempDir, snapshotArtifacts, artifactName)
+		if err = enclave.DownloadArtifact(ctx, artifactName, dstDir); err != nil {
+			return fmt.Errorf("failed to download artifact '%s': %w", artifactName, err)
+		}
+	}
+	contents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(tempDir, snapshotManifestFile), contents, 0600); err != nil {
+		return err
+	}
+	// Write to a temporary file first, to avoid leaving a broken snapshot behind
+	partialPath := snapshotPath + ".partial"
+	if err = createTarGz(tempDir, partialPath); err != nil {
+		os.Remove(partialPath)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(partialPath, snapshotPath)
+}
+
+// Returns the state of the devnet's chain.
+func dumpDevnetChainState(ctx context.Context, services []backend.Service) ([]byte, error) {
+	chain, ok := findChainService(services)
+	if !ok {
+		return nil, errors.New("the devnet has no execution client")
+	}
+	rpcEndpoint, ok := chain.Ports["rpc"]
+	if !ok {
+		return nil, fmt.Errorf("execution client '%s' has no RPC port", chain.Name)
+	}
+	client, err := rpc.DialContext(ctx, "http://"+rpcEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the execution client: %w", err)
+	}
+	defer client.Close()
+	state, err := dumpChainState(ctx, client)
+	if errors.Is(err, ErrStateDumpUnsupported) {
+		return nil, fmt.Errorf("%w. Snapshots need the devnet to run with `chain: {type: anvil}`", err)
+	}
+	return state, err
+}
+
+// Returns false for the artifacts that are created again when restoring a snapshot.
+func isSnapshotArtifact(artifactName string) bool {
+	// Created when expanding the services' templated env vars and args
+	isServiceExpansion := strings.HasPrefix(artifactName, "service_") && strings.Contains(artifactName, "_expanded_")
+	return !isServiceExpansion && artifactName != snapshotStateArtifact
+}
+
+// Returns the preparation tasks that upload the snapshot's artifacts and chain state to the enclave,
+// and the args that make the Kurtosis package restore it.
+// Artifacts rendered from the config's templates are skipped, since they're rendered again.
+func restoreSnapshotTasks(
+	snapshotPath string,
+	devnetConfig config.DevnetConfig,
+	enclave backend.Enclave,
+	tempDir string,
+) ([]prepTask, config.SnapshotArgs, error) {
+	var args config.SnapshotArgs
+	if devnetConfig.ChainType() != config.ChainTypeAnvil {
+		return nil, args, ErrSnapshotChainType
+	}
+	snapshotDir := filepath.Join(tempDir, "snapshot")
+	if err := extractTarGz(snapshotPath, snapshotDir); err != nil {
+		return nil, args, fmt.Errorf("failed to extract snapshot '%s': %w", snapshotPath, err)
+	}
+	manifest, err := readSnapshotManifest(snapshotDir)
+	if err != nil {
+		return nil, args, err
+	}
+	dataPath := filepath.Join(snapshotDir, snapshotArtifacts, snapshotDataArtifact, snapshotDataFile)
+	contents, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, args, fmt.Errorf("failed to read the snapshot's data: %w", err)
+	}
+	if err = json.Unmarshal(contents, &args.Data); err != nil {
+		return nil, args, fmt.Errorf("failed to parse the snapshot's data: %w", err)
+	}
+	args.ChainState = snapshotStateArtifact
+
+	tasks := []prepTask{snapshotUploadTask(enclave, filepath.Join(snapshotDir, snapshotChainDir), snapshotStateArtifact)}
+	for _, artifactName := range manifest.Artifacts {
+		if err = checkArtifactName(artifactName); err != nil {
+			return nil, args, err
+		}
+		if artifact, ok := devnetConfig.Artifacts[artifactName]; ok && !hasStaticFiles(artifact) {
+			continue
+		}
+		artifactDir := filepath.Join(snapshotDir, snapshotArtifacts, artifactName)
+		tasks = append(tasks, snapshotUploadTask(enclave, artifactDir, artifactName))
+	}
+	return tasks, args, nil
+}
+
+// Reads the snapshot's manifest, failing if the snapshot's format isn't supported.
+func readSnapshotManifest(snapshotDir string) (snapshotManifest, error) {
+	var manifest snapshotManifest
+	contents, err := os.ReadFile(filepath.Join(snapshotDir, snapshotManifestFile))
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read the snapshot's manifest: %w", err)
+	}
+	if err = json.Unmarshal(contents, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse the snapshot's manifest: %w", err)
+	}
+	if manifest.Version != snapshotVersion {
+		return manifest, fmt.Errorf("unsupported snapshot version %d, expected %d", manifest.Version, snapshotVersion)
+	}
+	return manifest, nil
+}
+
+// Returns a task uploading the dir as an artifact.
+func snapshotUploadTask(enclave backend.Enclave, dir string, artifactName string) prepTask {
+	return prepTask{
+		id:   "snapshot-artifact:" + artifactName,
+		kind: prepTaskUpload,
+		run: func(ctx context.Context, _ chan<- buildOutputLine) (string, error) {
+			err := enclave.UploadFiles(ctx, dir, artifactName)
+			if err != nil {
+				return "", fmt.Errorf("file uploading failed: %w", err)
+			}
+			return fmt.Sprintf("Uploaded '%s'", artifactName), nil
+		},
+	}
+}
+
+// Checks if the artifact's files are static, instead of being rendered from templates.
+func hasStaticFiles(artifact config.Artifact) bool {
+	for _, file := range artifact.Files {
+		if file.IsStatic() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package cmds_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const snapshotConfig = `
+chain:
+  type: anvil
+artifacts:
+  aggregator_config:
+    files:
+      config.yaml:
+        template: "rpc: {{.http_rpc_url}}"
+`
+
+const snapshotData = `{"keys": {"operator": {"address": "0x1234"}}, "addresses": {"avs": {"registry": "0x5678"}}}`
+
+func TestSnapshotSaveAndRestore(t *testing.T) {
+	t.Parallel()
+	chain := backendtest.NewStubChain(t, true)
+	fake := backendtest.NewFake()
+	enclave := fake.AddEnclave("devnet")
+	enclave.SetServices(chain.Service())
+	enclave.SetArtifact("devnet_data", backend.ArtifactFile{Path: "data.json", TextPreview: snapshotData})
+	enclave.SetArtifact("avs_addresses",
+		backend.ArtifactFile{Path: "addresses.json", TextPreview: `{"addresses": {"registry": "0x5678"}}`})
+	enclave.SetArtifact("aggregator_config", backend.ArtifactFile{Path: "config.yaml", TextPreview: "rpc: old"})
+	enclave.SetArtifact("service_aggregator_expanded_env_var_RPC_URL",
+		backend.ArtifactFile{Path: "expanded.txt", TextPreview: "http://172.16.0.2:8545"})
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	err := cmds.SaveSnapshot(context.Background(), enclave, snapshotPath)
+	require.NoError(t, err)
+
+	devnetConfig, err := config.Unmarshal([]byte(snapshotConfig))
+	require.NoError(t, err)
+	opts := fakeStartOptions(fake)
+	opts.DevnetName = "restored"
+	opts.DevnetConfig = devnetConfig
+	opts.SnapshotPath = snapshotPath
+	err = cmds.Start(context.Background(), opts)
+	require.NoError(t, err)
+
+	// Templated artifacts are rendered again, so they aren't restored
+	restored := fake.Enclave("restored")
+	uploads := restored.Uploads()
+	require.ElementsMatch(t, []string{"devnet_chain_state", "devnet_data", "avs_addresses"}, uploadedNames(uploads))
+
+	state, err := restored.InspectArtifact(context.Background(), "devnet_chain_state")
+	require.NoError(t, err)
+	require.Equal(t, []backend.ArtifactFile{{Path: "chain-state.json", TextPreview: chain.State()}}, state)
+
+	addresses, err := cmds.ReadJsonArtifact(context.Background(), restored, "avs_addresses")
+	require.NoError(t, err)
+	address, ok := cmds.LookupAddress(addresses, "registry")
+	require.True(t, ok)
+	require.Equal(t, "0x5678", address)
+
+	var runArgs []string
+	for _, call := range fake.Calls() {
+		if call.Method == "RunPackage" && call.Args[0] == "restored" {
+			runArgs = call.Args
+		}
+	}
+	var params struct {
+		Snapshot config.SnapshotArgs `yaml:"snapshot"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte(runArgs[2]), &params))
+	require.Equal(t, "devnet_chain_state", params.Snapshot.ChainState)
+	require.Equal(t, map[string]interface{}{"avs": map[string]interface{}{"registry": "0x5678"}},
+		params.Snapshot.Data["addresses"])
+}
+
+func TestStartFromSnapshotNeedsAnvil(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	opts := fakeStartOptions(fake)
+	opts.SnapshotPath = filepath.Join(t.TempDir(), "snapshot.tar.gz")
+
+	err := cmds.Start(context.Background(), opts)
+	require.ErrorIs(t, err, cmds.ErrSnapshotChainType)
+}
+
+// Returns the names of the uploaded artifacts.
+func uploadedNames(uploads map[string]string) []string {
+	names := make([]string, 0, len(uploads))
+	for name := range uploads {
+		names = append(names, name)
+	}
+	return names
+}
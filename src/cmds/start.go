@@ -5,19 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"slices"
+	"syscall"
+	"time"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
 	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
 	"github.com/Layr-Labs/avs-devnet/src/config"
-	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
 	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
-	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/enclaves"
-	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/starlark_run_config"
 	"github.com/urfave/cli/v2"
 )
 
@@ -40,16 +40,36 @@ func StartCmd(ctx *cli.Context) error {
 	if err != nil {
 		return cli.Exit(err, 1)
 	}
+	b, err := backend.New(flags.BackendFlag.Get(ctx))
+	if err != nil {
+		return toExitError(err, devnetName)
+	}
 	workingDir := filepath.Dir(configPath)
 	opts := StartOptions{
 		KurtosisPackageUrl: pkgName,
 		DevnetName:         devnetName,
 		WorkingDir:         workingDir,
 		DevnetConfig:       devnetConfig,
-	}
-	err = Start(ctx.Context, opts)
+		KeepOnFailure:      flags.KeepOnFailureFlag.Get(ctx),
+		Verbose:            flags.VerboseFlag.Get(ctx),
+		Rebuild:            flags.RebuildFlag.Get(ctx),
+		AllowDirty:         flags.AllowDirtyFlag.Get(ctx),
+		Jobs:               flags.JobsFlag.Get(ctx),
+		StrictResponses:    flags.StrictResponsesFlag.Get(ctx),
+		SnapshotPath:       flags.FromSnapshotFlag.Get(ctx),
+		Backend:            b,
+	}
+	// Cancel the start on SIGINT/SIGTERM
+	signalCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-signalCtx.Done()
+		// Restore default behavior, so a second signal kills the process
+		stop()
+	}()
+	err = Start(signalCtx, opts)
 	if err != nil {
-		return cli.Exit(err, 1)
+		return toExitError(err, devnetName)
 	}
 	return nil
 }
@@ -65,289 +85,216 @@ type StartOptions struct {
 	WorkingDir string
 	// Devnet configuration
 	DevnetConfig config.DevnetConfig
+	// Keep the devnet around if the start fails or is interrupted
+	KeepOnFailure bool
+	// Print the full output of each step
+	Verbose bool
+	// Rebuild images and local repo scripts even if their inputs didn't change
+	Rebuild bool
+	// Allow deploying local repo refs that are checked out with uncommitted changes
+	AllowDirty bool
+	// Maximum number of preparation tasks (image builds, uploads, downloads) to run in parallel.
+	// Defaults to flags.DefaultJobs if not positive.
+	Jobs int
+	// Fail on Kurtosis response lines this version doesn't recognize.
+	// By default, they're skipped with a single warning, to stay compatible with newer Kurtosis versions.
+	StrictResponses bool
+	// Path to a snapshot saved with SaveSnapshot. If set, the chain starts from the snapshot's state,
+	// and its artifacts are uploaded instead of generating the keys and running the deployments.
+	SnapshotPath string
+	// Backend to start the devnet in. Defaults to Kurtosis.
+	Backend backend.Backend
 }
 
 // Starts the devnet with the given context.
 func Start(ctx context.Context, opts StartOptions) error {
-	kurtosisCtx, err := kurtosis.InitKurtosisContext()
-	if err != nil {
-		return fmt.Errorf("failed to initialize kurtosis context: %w", err)
+	b := opts.Backend
+	if b == nil {
+		kurtosisBackend, err := backend.NewKurtosis()
+		if err != nil {
+			return fmt.Errorf("failed to initialize kurtosis context: %w", err)
+		}
+		b = kurtosisBackend
 	}
-	if kurtosisCtx.EnclaveExists(ctx, opts.DevnetName) {
-		return errors.New("devnet already running")
+	if b.EnclaveExists(ctx, opts.DevnetName) {
+		return &errdefs.DevnetExistsError{Name: opts.DevnetName}
 	}
-	enclaveCtx, err := kurtosisCtx.CreateEnclave(ctx, opts.DevnetName)
+	enclave, err := b.CreateEnclave(ctx, opts.DevnetName)
 	if err != nil {
 		return fmt.Errorf("failed to create enclave: %w", err)
 	}
 
-	err = buildDockerImages(opts.WorkingDir, opts.DevnetConfig)
-	if err != nil {
-		return fmt.Errorf("failed when building images: %w", err)
+	err = startInEnclave(ctx, opts, enclave)
+	if ctx.Err() != nil {
+		// The start was interrupted
+		cleanupErr := cleanupFailedStart(ctx, b, enclave, opts, false)
+		return errors.Join(fmt.Errorf("devnet start interrupted: %w", ctx.Err()), cleanupErr)
 	}
-
-	err = uploadLocalRepos(opts.WorkingDir, opts.DevnetConfig, enclaveCtx)
 	if err != nil {
-		return fmt.Errorf("failed when uploading local repos: %w", err)
+		cleanupErr := cleanupFailedStart(ctx, b, enclave, opts, true)
+		return errors.Join(err, cleanupErr)
 	}
+	return nil
+}
+
+// Prepares the enclave and runs the Kurtosis package inside it.
+func startInEnclave(ctx context.Context, opts StartOptions, enclave backend.Enclave) error {
+	reporter := progress_reporters.NewProgressBarReporter(opts.Verbose)
 
-	err = uploadStaticFiles(ctx, opts.WorkingDir, opts.DevnetConfig, enclaveCtx)
+	devnetConfig, err := prepareEnclave(ctx, reporter, opts, enclave)
 	if err != nil {
-		return fmt.Errorf("failed when uploading static files: %w", err)
+		return err
 	}
 
-	starlarkConfig := starlark_run_config.NewRunStarlarkConfig()
-	starlarkConfig.SerializedParams = string(opts.DevnetConfig.Marshal())
-
 	kurtosisPkg := opts.KurtosisPackageUrl
 	if kurtosisPkg == "" {
 		kurtosisPkg = flags.DefaultKurtosisPackage
 	}
 
-	fmt.Println("Starting devnet...")
-
-	var responseChan chan progress_reporters.KurtosisResponse
-	// TODO: use cancel func if needed
-	// var cancel context.CancelFunc
-	if strings.HasPrefix(kurtosisPkg, "github.com/") {
-		responseChan, _, err = enclaveCtx.RunStarlarkRemotePackage(ctx, kurtosisPkg, starlarkConfig)
-	} else {
-		responseChan, _, err = enclaveCtx.RunStarlarkPackage(ctx, kurtosisPkg, starlarkConfig)
-	}
+	responseChan, cancel, err := enclave.RunPackage(ctx, kurtosisPkg, string(devnetConfig.Marshal()))
 	if err != nil {
 		return fmt.Errorf("failed when running kurtosis package: %w", err)
 	}
+	// Stops the run if we return early (e.g. when interrupted)
+	defer cancel()
 
-	reporter := progress_reporters.NewProgressBarReporter()
-	return progress_reporters.ReportProgress(reporter, responseChan)
+	reportOpts := progress_reporters.ReportOptions{SkipUnexpectedResponses: !opts.StrictResponses}
+	return progress_reporters.ReportProgressWithOptions(reporter, responseChan, reportOpts)
 }
 
-// Uploads the local repositories to the enclave.
-func uploadLocalRepos(dirContext string, config config.DevnetConfig, enclaveCtx *enclaves.EnclaveContext) error {
-	for _, deployment := range config.Deployments {
-		if deployment.Repo == "" {
-			continue
-		}
-		repoUrl, err := url.Parse(deployment.Repo)
-		if err != nil {
-			return fmt.Errorf("repo '%s' is invalid: %w", deployment.Repo, err)
-		}
-		if !isLocalUrl(repoUrl.Scheme) {
-			continue
-		}
-		absPath := ensureAbs(dirContext, repoUrl.Path)
-		err = uploadLocalRepo(deployment, absPath, enclaveCtx)
-		if err != nil {
-			return fmt.Errorf("local repo '%s' uploading failed: %w", absPath, err)
-		}
+// Builds the local images and uploads the local repos and static files to the enclave.
+// All of these are run in a single worker pool, limited by `opts.Jobs`.
+// Returns the config to pass to the Kurtosis package, with the deployer images built from private repos.
+func prepareEnclave(
+	ctx context.Context,
+	reporter progress_reporters.Reporter,
+	opts StartOptions,
+	enclave backend.Enclave,
+) (config.DevnetConfig, error) {
+	devnetConfig := opts.DevnetConfig
+	maxJobs := opts.Jobs
+	if maxJobs <= 0 {
+		maxJobs = flags.DefaultJobs
+	}
+	creds, err := loadCredentials(devnetConfig)
+	if err != nil {
+		return devnetConfig, fmt.Errorf("failed when loading credentials: %w", err)
 	}
-	return nil
-}
-
-// Uploads the script of a single deployment from the repo at the given path to an enclave.
-// The deployment script is flattened and uploaded with the deployment name suffixed with '-script'.
-// The resulting artifact's structure is similar to the repo's structure, but with only the script and foundry config.
-// TODO: to avoid having foundry as a dependency, we should use it via docker.
-func uploadLocalRepo(deployment config.Deployment, repoPath string, enclaveCtx *enclaves.EnclaveContext) error {
-	scriptPath := deployment.GetScriptPath()
-	scriptOrigin := filepath.Join(repoPath, deployment.ContractsPath, scriptPath)
-
-	// Output files in a temp dir
-	outputDir, err := os.MkdirTemp(os.TempDir(), "avs-devnet-")
+	// Temporary files of all the tasks are stored here
+	tempDir, err := os.MkdirTemp(os.TempDir(), "avs-devnet-")
 	if err != nil {
-		return fmt.Errorf("tempdir creation failed: %w", err)
+		return devnetConfig, fmt.Errorf("tempdir creation failed: %w", err)
 	}
-	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(tempDir)
 
-	scriptDestination := filepath.Join(outputDir, deployment.ContractsPath, scriptPath)
-
-	err = os.MkdirAll(filepath.Dir(scriptDestination), 0700)
+	imageTasks, err := buildTasks(opts.WorkingDir, devnetConfig, opts.Rebuild)
 	if err != nil {
-		return fmt.Errorf("output dir creation failed: %w", err)
+		return devnetConfig, fmt.Errorf("failed when building images: %w", err)
 	}
-
-	// Verify the script exists
-	if !fileExists(scriptOrigin) {
-		return fmt.Errorf("file '%s' doesn't exist", scriptOrigin)
+	if opts.SnapshotPath != "" {
+		snapshotTasks, snapshotArgs, snapshotErr := restoreSnapshotTasks(opts.SnapshotPath, devnetConfig, enclave, tempDir)
+		if snapshotErr != nil {
+			return devnetConfig, snapshotErr
+		}
+		// The deployments are skipped, so their images and repos aren't needed
+		err = runPreparation(ctx, reporter, slices.Concat(imageTasks, snapshotTasks), maxJobs)
+		if err != nil {
+			return devnetConfig, fmt.Errorf("failed when preparing the devnet: %w", err)
+		}
+		return devnetConfig.WithSnapshot(snapshotArgs)
 	}
-
-	originContractsDir := filepath.Join(repoPath, deployment.ContractsPath)
-	// Install deps
-	output, err := executeCmdInsideDir(originContractsDir, "forge install").CombinedOutput()
+	deployerTasks, deployerImages, err := privateDeployerTasks(devnetConfig, creds, tempDir, opts.Rebuild)
 	if err != nil {
-		return fmt.Errorf("forge install failed: %w, with output: %s", err, string(output))
+		return devnetConfig, fmt.Errorf("failed when building deployer images: %w", err)
 	}
-	// Flatten the script into a single file before upload
-	flattenCmd := fmt.Sprintf("forge flatten -o %s %s", scriptDestination, scriptOrigin)
-	output, err = executeCmdInsideDir(originContractsDir, flattenCmd).CombinedOutput()
+	localRepoOpts := localRepoOptions{rebuild: opts.Rebuild, allowDirty: opts.AllowDirty, tempDir: tempDir}
+	repoTasks, err := localRepoTasks(opts.WorkingDir, devnetConfig, enclave, localRepoOpts)
 	if err != nil {
-		return fmt.Errorf("script flattening failed: %w, with output: %s", err, string(output))
+		return devnetConfig, fmt.Errorf("failed when uploading local repos: %w", err)
 	}
-
-	// Copy the foundry config inside the contracts dir
-	foundryConfigRelPath := filepath.Join(deployment.ContractsPath, "foundry.toml")
-	src := filepath.Join(repoPath, foundryConfigRelPath)
-	dst := filepath.Join(outputDir, foundryConfigRelPath)
-	err = fileCopy(src, dst)
+	artifactTasks, err := staticArtifactTasks(opts.WorkingDir, devnetConfig, enclave, creds, tempDir)
 	if err != nil {
-		return fmt.Errorf("failed when copying foundry.toml: %w", err)
+		return devnetConfig, fmt.Errorf("failed when uploading static files: %w", err)
 	}
 
-	// Upload the file to the enclave
-	artifactName := deployment.Name + "-script"
-	_, _, err = enclaveCtx.UploadFiles(outputDir, artifactName)
+	tasks := slices.Concat(imageTasks, deployerTasks, repoTasks, artifactTasks)
+	err = runPreparation(ctx, reporter, tasks, maxJobs)
 	if err != nil {
-		return fmt.Errorf("file uploading failed: %w", err)
+		return devnetConfig, fmt.Errorf("failed when preparing the devnet: %w", err)
 	}
-	return nil
+	return devnetConfig.WithDeployerImages(deployerImages)
 }
 
-func uploadStaticFiles(
+// Number of log lines to print per service when a start fails.
+const failedStartLogLines = 50
+
+// Cleans up the enclave after a failed or interrupted start.
+// The enclave is destroyed, unless KeepOnFailure is set, in which case its contents are printed.
+// If `printLogs` is set, the services' logs are printed before destroying the enclave.
+func cleanupFailedStart(
 	ctx context.Context,
-	dirContext string,
-	config config.DevnetConfig,
-	enclaveCtx *enclaves.EnclaveContext,
+	b backend.Backend,
+	enclave backend.Enclave,
+	opts StartOptions,
+	printLogs bool,
 ) error {
-	for artifactName, artifactDetails := range config.Artifacts {
-		numStaticFiles := 0
-		numTemplates := 0
-		for _, fileAttrs := range artifactDetails.Files {
-			switch {
-			case fileAttrs.StaticFile != nil:
-				numStaticFiles += 1
-			case fileAttrs.Template != nil:
-				numTemplates += 1
-			default:
-				return errors.New("artifact must have either a static file or a template")
-			}
-		}
-		if numStaticFiles > 0 && numTemplates > 0 {
-			return errors.New("artifacts with both static files and templates are not yet supported")
-		}
-		// Skip artifacts with templates only
-		if numStaticFiles == 0 {
-			continue
-		}
-
-		// Output files in a temp dir
-		outputDir, err := os.MkdirTemp(os.TempDir(), "avs-devnet-")
-		if err != nil {
-			return fmt.Errorf("tempdir creation failed: %w", err)
-		}
-		defer os.RemoveAll(outputDir)
-		for outFileName, fileAttrs := range artifactDetails.Files {
-			rawUrl := *fileAttrs.StaticFile
-			destinationFilePath := filepath.Join(outputDir, outFileName)
-			err = uploadStaticFile(ctx, rawUrl, dirContext, destinationFilePath)
-			if err != nil {
-				return err
-			}
-		}
-		// Upload temp dir to enclave
-		_, _, err = enclaveCtx.UploadFiles(outputDir, artifactName)
-		if err != nil {
-			return fmt.Errorf("file uploading failed: %w", err)
-		}
-	}
-	return nil
-}
-
-func uploadStaticFile(ctx context.Context, rawUrl string, dirContext string, destinationFilePath string) error {
-	srcUrl, err := url.Parse(rawUrl)
-	if err != nil {
-		return fmt.Errorf("url '%s' is invalid: %w", rawUrl, err)
-	}
-	if isLocalUrl(srcUrl.Scheme) {
-		// Copy the file to the temp dir
-		originFilePath := ensureAbs(dirContext, rawUrl)
-		err = fileCopy(originFilePath, destinationFilePath)
-		if err != nil {
-			return fmt.Errorf("failed when copying file: %w", err)
-		}
+	// The original context might have been cancelled already
+	ctx = context.WithoutCancel(ctx)
+	if opts.KeepOnFailure {
+		printEnclaveContents(ctx, enclave)
 		return nil
 	}
-	// The file is remote. Download the file
-	// 1. do GET request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+	if printLogs {
+		printServiceLogs(ctx, enclave)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	fmt.Println("Destroying devnet...")
+	err := b.DestroyEnclave(ctx, opts.DevnetName)
 	if err != nil {
-		return fmt.Errorf("failed HTTP GET request: %w", err)
-	}
-	defer resp.Body.Close()
-	// 2. check status code
-	if resp.StatusCode < 200 && resp.StatusCode >= 300 {
-		return fmt.Errorf("GET request failed with status code: %d", resp.StatusCode)
-	}
-
-	// 3. dump response to file
-	dstFile, err := os.Create(destinationFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed when downloading file: %w", err)
+		return fmt.Errorf("failed to destroy enclave: %w", err)
 	}
+	fmt.Println("Devnet destroyed")
 	return nil
 }
 
-// Builds the local docker images for the services in the configuration.
-// Starts multiple builds in parallel.
-func buildDockerImages(baseDir string, config config.DevnetConfig) error {
-	errChan := make(chan error)
-	numBuilds := 0
-	for _, service := range config.Services {
-		if service.BuildContext != nil {
-			numBuilds += 1
-			buildContext := ensureAbs(baseDir, *service.BuildContext)
-			go func() {
-				errChan <- buildWithDocker(service.Image, buildContext, service.BuildFile)
-			}()
-		} else if service.BuildCmd != nil {
-			numBuilds += 1
-			go func() {
-				errChan <- buildWithCustomCmd(service.Image, baseDir, *service.BuildCmd)
-			}()
+// Prints the last log lines of each service in the enclave.
+func printServiceLogs(ctx context.Context, enclave backend.Enclave) {
+	logs, err := enclave.ServiceLogs(ctx, failedStartLogLines)
+	if err != nil {
+		fmt.Println("Failed to collect service logs:", err)
+		return
+	}
+	serviceNames := make([]string, 0, len(logs))
+	for name := range logs {
+		serviceNames = append(serviceNames, name)
+	}
+	slices.Sort(serviceNames)
+	for _, name := range serviceNames {
+		fmt.Printf("==> Logs of service '%s' <==\n", name)
+		for _, line := range logs[name] {
+			fmt.Println(line)
 		}
 	}
-	// Check that all builds were successful and fail if not
-	errs := make([]error, 0, numBuilds)
-	for range numBuilds {
-		errs = append(errs, <-errChan)
-	}
-	return errors.Join(errs...)
 }
 
-// Builds a docker image with the given name from the given build context and (optional) file.
-func buildWithDocker(imageName string, buildContext string, buildFile *string) error {
-	cmdArgs := []string{"build", buildContext, "-t", imageName}
-	if buildFile != nil {
-		cmdArgs = append(cmdArgs, "-f", *buildFile)
-	}
-	cmd := exec.Command("docker", cmdArgs...)
-	fmt.Println("Building image", imageName)
-	output, err := cmd.CombinedOutput()
+// Prints the services and artifacts left behind in the enclave.
+func printEnclaveContents(ctx context.Context, enclave backend.Enclave) {
+	devnetName := enclave.Name()
+	fmt.Printf("Devnet '%s' was kept with the following contents:\n", devnetName)
+	services, err := enclave.ListServices(ctx)
 	if err != nil {
-		return fmt.Errorf("building image '%s' failed: %w\n%s", imageName, err, output)
+		fmt.Println("Failed to list services:", err)
 	}
-	return nil
-}
-
-// Builds a docker image with the given name with a custom command.
-// The command is executed inside a shell.
-func buildWithCustomCmd(imageName, baseDir, buildCmd string) error {
-	cmd := executeCmdInsideDir(baseDir, buildCmd)
-	fmt.Println("Building image", imageName)
-	output, err := cmd.CombinedOutput()
+	for _, service := range services {
+		fmt.Println("  service:", service.Name)
+	}
+	artifacts, err := enclave.ListArtifacts(ctx)
 	if err != nil {
-		return fmt.Errorf("building image '%s' failed: %w\n%s", imageName, err, output)
+		fmt.Println("Failed to list artifacts:", err)
 	}
-	return nil
+	for _, artifact := range artifacts {
+		fmt.Println("  artifact:", artifact)
+	}
+	fmt.Printf("Run `avs-devnet stop -n %s` to remove it\n", devnetName)
 }
 
 func ensureAbs(baseDir string, path string) string {
@@ -358,9 +305,24 @@ func ensureAbs(baseDir string, path string) string {
 	return absPath
 }
 
-func executeCmdInsideDir(dir, cmd string) *exec.Cmd {
+func executeCmdInsideDir(ctx context.Context, dir, cmd string) *exec.Cmd {
 	fullCmd := fmt.Sprintf("cd %s && %s", dir, cmd)
-	return exec.Command("sh", "-c", fullCmd)
+	return newCmd(ctx, "sh", "-c", fullCmd)
+}
+
+// Time to wait for a cancelled command to exit before killing it.
+const cmdCancelWaitDelay = 10 * time.Second
+
+// Creates a command that's stopped, along with its subprocesses, when the context is done.
+func newCmd(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	// Run it in its own process group, so we can signal any subprocesses too
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = cmdCancelWaitDelay
+	return cmd
 }
 
 func fileCopy(src, dst string) error {
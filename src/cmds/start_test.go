@@ -4,10 +4,15 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
 	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
 	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,6 +50,71 @@ func startDevnet(t *testing.T, devnetConfig config.DevnetConfig, workingDir stri
 	require.NoError(t, err, "Failed to start new devnet")
 }
 
+func fakeStartOptions(fake *backendtest.Fake) cmds.StartOptions {
+	return cmds.StartOptions{
+		DevnetName:   "devnet",
+		WorkingDir:   examplesDir,
+		DevnetConfig: config.DefaultConfig(),
+		Backend:      fake,
+	}
+}
+
+func TestStartWithFakeBackend(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	opts := fakeStartOptions(fake)
+
+	err := cmds.Start(context.Background(), opts)
+	require.NoError(t, err)
+	require.NotNil(t, fake.Enclave("devnet"))
+	require.Equal(t, []string{"EnclaveExists", "CreateEnclave", "RunPackage"}, fake.Methods())
+
+	runCall := fake.Calls()[2]
+	require.Equal(t, []string{"devnet", flags.DefaultKurtosisPackage, config.DefaultConfigStr()}, runCall.Args)
+}
+
+func TestStartExistingDevnet(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	fake.AddEnclave("devnet")
+
+	err := cmds.Start(context.Background(), fakeStartOptions(fake))
+	var existsErr *errdefs.DevnetExistsError
+	require.ErrorAs(t, err, &existsErr)
+	require.Equal(t, []string{"EnclaveExists"}, fake.Methods())
+}
+
+func TestStartFailureDestroysEnclave(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	fake.QueueResponses(
+		backendtest.Progress("Interpreting plan - execution will begin shortly", 0, 0),
+		backendtest.ExecutionError("deployment failed"),
+	)
+
+	err := cmds.Start(context.Background(), fakeStartOptions(fake))
+	var executionErr *errdefs.ExecutionError
+	require.ErrorAs(t, err, &executionErr)
+	require.Nil(t, fake.Enclave("devnet"))
+	require.Equal(t, []string{"EnclaveExists", "CreateEnclave", "RunPackage", "ServiceLogs", "DestroyEnclave"}, fake.Methods())
+}
+
+func TestStartFailureKeepsEnclave(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	fake.QueueResponses(
+		backendtest.Progress("Interpreting plan - execution will begin shortly", 0, 0),
+		backendtest.ExecutionError("deployment failed"),
+	)
+	opts := fakeStartOptions(fake)
+	opts.KeepOnFailure = true
+
+	err := cmds.Start(context.Background(), opts)
+	require.Error(t, err)
+	require.NotNil(t, fake.Enclave("devnet"))
+	require.NotContains(t, fake.Methods(), "DestroyEnclave")
+}
+
 func TestStartDefaultDevnet(t *testing.T) {
 	t.Parallel()
 	startDevnet(t, config.DefaultConfig(), examplesDir)
@@ -70,3 +140,49 @@ func TestStartLocalHelloWorld(t *testing.T) {
 	helloWorldRepo := filepath.Join(examplesDir, "hello-world-avs")
 	startDevnet(t, devnetConfig, helloWorldRepo)
 }
+
+// Starts the example on both backends, checking the Docker backend's devnet has the same services and artifacts.
+// The Kurtosis devnet also has the ethereum-package's, which the Docker backend replaces with a single Anvil node.
+func TestStartIncredibleSquaringOnBothBackends(t *testing.T) {
+	t.Parallel()
+	examplePath := filepath.Join(examplesDir, "incredible_squaring.yaml")
+	parsedConfig, err := config.LoadFromPath(examplePath)
+	require.NoError(t, err, "Failed to parse example config")
+	ctx := context.Background()
+
+	serviceNames := make(map[string][]string)
+	artifactNames := make(map[string][]string)
+	for _, kind := range []string{backend.KindKurtosis, backend.KindDocker} {
+		b, err := backend.New(kind)
+		require.NoError(t, err)
+		name, err := cmds.ToValidEnclaveName(t.Name() + "-" + kind)
+		require.NoError(t, err, "Failed to generate test name")
+		_ = cmds.StopWithBackend(ctx, b, name)
+		t.Cleanup(func() { _ = cmds.StopWithBackend(ctx, b, name) })
+
+		err = cmds.Start(ctx, cmds.StartOptions{
+			KurtosisPackageUrl: filepath.Join(rootDir, "kurtosis_package"),
+			DevnetName:         name,
+			WorkingDir:         examplesDir,
+			DevnetConfig:       parsedConfig,
+			Backend:            b,
+		})
+		require.NoError(t, err, "Failed to start new devnet on %s", kind)
+
+		enclave, err := b.GetEnclave(ctx, name)
+		require.NoError(t, err)
+		services, err := enclave.ListServices(ctx)
+		require.NoError(t, err)
+		for _, service := range services {
+			serviceNames[kind] = append(serviceNames[kind], service.Name)
+		}
+		artifactNames[kind], err = enclave.ListArtifacts(ctx)
+		require.NoError(t, err)
+	}
+
+	dockerServices := slices.DeleteFunc(serviceNames[backend.KindDocker], func(name string) bool {
+		return name == "el-1-anvil"
+	})
+	require.Subset(t, serviceNames[backend.KindKurtosis], dockerServices)
+	require.Subset(t, artifactNames[backend.KindKurtosis], artifactNames[backend.KindDocker])
+}
@@ -0,0 +1,445 @@
+package cmds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Number of times a download is attempted before failing.
+const downloadAttempts = 4
+
+// Maximum number of redirects followed when downloading a file.
+const maxDownloadRedirects = 10
+
+// Time to wait before the first download retry. It's doubled after each retry.
+const downloadInitialBackoff = time.Second
+
+// Error returned when a download fails with an unsuccessful status code.
+type httpStatusError struct {
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET request failed with status code: %d", e.statusCode)
+}
+
+// Returns the preparation tasks that upload the artifacts with static files to the enclave.
+// Each file is fetched by its own task, and each artifact is uploaded once all of its files are fetched.
+// Remote files are cached on disk, and the cached copy is used when they can't be downloaded.
+func staticArtifactTasks(
+	dirContext string,
+	config config.DevnetConfig,
+	enclave backend.Enclave,
+	creds credentials,
+	tempDir string,
+) ([]prepTask, error) {
+	artifactNames := make([]string, 0, len(config.Artifacts))
+	for artifactName := range config.Artifacts {
+		artifactNames = append(artifactNames, artifactName)
+	}
+	sort.Strings(artifactNames)
+
+	tasks := make([]prepTask, 0)
+	for _, artifactName := range artifactNames {
+		artifactDetails := config.Artifacts[artifactName]
+		numStaticFiles := 0
+		numTemplates := 0
+		for _, fileAttrs := range artifactDetails.Files {
+			switch {
+			case fileAttrs.IsStatic():
+				numStaticFiles += 1
+			case fileAttrs.Template != nil:
+				numTemplates += 1
+			default:
+				return nil, errors.New("artifact must have either a static file, a static dir, or a template")
+			}
+		}
+		if numStaticFiles > 0 && numTemplates > 0 {
+			return nil, errors.New("artifacts with both static files and templates are not yet supported")
+		}
+		// Skip artifacts with templates only
+		if numStaticFiles == 0 {
+			continue
+		}
+
+		// Each artifact's files are stored in their own dir
+		outputDir := filepath.Join(tempDir, "artifacts", artifactName)
+		fileNames := make([]string, 0, len(artifactDetails.Files))
+		for fileName := range artifactDetails.Files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+
+		uploadTask := prepTask{
+			id:   "artifact:" + artifactName,
+			kind: prepTaskUpload,
+			deps: make([]string, 0, len(fileNames)),
+			run: func(ctx context.Context, _ chan<- buildOutputLine) (string, error) {
+				err := enclave.UploadFiles(ctx, outputDir, artifactName)
+				if err != nil {
+					return "", fmt.Errorf("file uploading failed: %w", err)
+				}
+				return fmt.Sprintf("Uploaded '%s'", artifactName), nil
+			},
+		}
+		for _, fileName := range fileNames {
+			fileAttrs := artifactDetails.Files[fileName]
+			fileTask := prepTask{
+				id:   "artifact-file:" + artifactName + "/" + fileName,
+				kind: prepTaskDownload,
+				run: func(ctx context.Context, _ chan<- buildOutputLine) (string, error) {
+					destinationPath := filepath.Join(outputDir, fileName)
+					fromCache, err := fetchStaticSource(ctx, fileAttrs, dirContext, creds, destinationPath)
+					if err != nil {
+						return "", fmt.Errorf("failed to fetch '%s' of artifact '%s': %w", fileName, artifactName, err)
+					}
+					if fromCache {
+						return fmt.Sprintf("Using cached '%s'", fileName), nil
+					}
+					return fmt.Sprintf("Fetched '%s'", fileName), nil
+				},
+			}
+			uploadTask.deps = append(uploadTask.deps, fileTask.id)
+			tasks = append(tasks, fileTask)
+		}
+		tasks = append(tasks, uploadTask)
+	}
+	return tasks, nil
+}
+
+// Fetches the static file or dir into the given destination, inside the artifact's dir.
+// Globs, dirs, and extracted archives are stored in a dir at the destination, preserving their relative structure.
+// Returns true if a remote file was taken from the download cache.
+func fetchStaticSource(
+	ctx context.Context,
+	fileAttrs config.ArtifactFile,
+	dirContext string,
+	creds credentials,
+	destinationPath string,
+) (bool, error) {
+	switch {
+	case fileAttrs.StaticFile != nil && fileAttrs.StaticDir != nil:
+		return false, errors.New("only one of static_file and static_dir can be specified")
+	case fileAttrs.StaticDir != nil:
+		if fileAttrs.Extract || fileAttrs.Sha256 != nil {
+			return false, errors.New("extract and sha256 can't be used with static_dir")
+		}
+		srcDir := ensureAbs(dirContext, *fileAttrs.StaticDir)
+		return false, fsutil.CopyDir(srcDir, destinationPath, nil)
+	case isLocalGlob(*fileAttrs.StaticFile):
+		if fileAttrs.Extract || fileAttrs.Sha256 != nil {
+			return false, errors.New("extract and sha256 can't be used with glob patterns")
+		}
+		return false, copyGlobMatches(ensureAbs(dirContext, *fileAttrs.StaticFile), destinationPath)
+	case fileAttrs.Extract:
+		err := os.MkdirAll(filepath.Dir(destinationPath), 0700)
+		if err != nil {
+			return false, fmt.Errorf("output dir creation failed: %w", err)
+		}
+		// Fetch the archive next to the destination, and extract it afterwards
+		archivePath := destinationPath + ".archive"
+		defer os.Remove(archivePath)
+		fromCache, err := fetchStaticFile(ctx, fileAttrs, dirContext, creds, archivePath)
+		if err != nil {
+			return false, err
+		}
+		return fromCache, extractArchive(archivePath, archiveName(*fileAttrs.StaticFile), destinationPath)
+	default:
+		err := os.MkdirAll(filepath.Dir(destinationPath), 0700)
+		if err != nil {
+			return false, fmt.Errorf("output dir creation failed: %w", err)
+		}
+		return fetchStaticFile(ctx, fileAttrs, dirContext, creds, destinationPath)
+	}
+}
+
+// Copies or downloads the static file to the given destination, verifying its checksum if specified.
+// Returns true if a remote file was taken from the download cache.
+func fetchStaticFile(
+	ctx context.Context,
+	fileAttrs config.ArtifactFile,
+	dirContext string,
+	creds credentials,
+	destinationFilePath string,
+) (bool, error) {
+	rawUrl := *fileAttrs.StaticFile
+	expectedHash := ""
+	if fileAttrs.Sha256 != nil {
+		expectedHash = strings.ToLower(strings.TrimPrefix(*fileAttrs.Sha256, "sha256:"))
+	}
+	srcUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return false, fmt.Errorf("url '%s' is invalid: %w", redactUrl(rawUrl), err)
+	}
+	if isLocalUrl(srcUrl.Scheme) {
+		// Copy the file to the temp dir
+		originFilePath := ensureAbs(dirContext, rawUrl)
+		err = fileCopy(originFilePath, destinationFilePath)
+		if err != nil {
+			return false, fmt.Errorf("failed when copying file: %w", err)
+		}
+		return false, verifyChecksum(destinationFilePath, expectedHash)
+	}
+
+	// The cache is optional, so we continue without it on errors
+	cachePath, cacheErr := downloadCachePath(rawUrl)
+	// Files with a known checksum don't need to be downloaded again
+	if cacheErr == nil && expectedHash != "" && verifyChecksum(cachePath, expectedHash) == nil {
+		return true, copyFromCache(cachePath, destinationFilePath)
+	}
+
+	err = downloadWithRetries(ctx, rawUrl, creds, destinationFilePath)
+	if err != nil {
+		// Fall back to the cached copy when offline or the server is failing
+		if ctx.Err() == nil && isRetryableDownloadError(err) && cacheErr == nil && fileExists(cachePath) {
+			if verifyChecksum(cachePath, expectedHash) == nil {
+				return true, copyFromCache(cachePath, destinationFilePath)
+			}
+		}
+		return false, err
+	}
+	err = verifyChecksum(destinationFilePath, expectedHash)
+	if err != nil {
+		return false, fmt.Errorf("downloaded file from '%s': %w", redactUrl(rawUrl), err)
+	}
+	if cacheErr == nil {
+		storeDownloadCache(destinationFilePath, cachePath)
+	}
+	return false, nil
+}
+
+// Downloads the file at the URL to the given destination.
+// Retries with exponential backoff on network errors, server errors, and rate limiting.
+func downloadWithRetries(ctx context.Context, rawUrl string, creds credentials, destinationFilePath string) error {
+	backoff := downloadInitialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = downloadFile(ctx, rawUrl, creds, destinationFilePath)
+		if err == nil || attempt == downloadAttempts || !isRetryableDownloadError(err) {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download '%s': %w", redactUrl(rawUrl), err)
+	}
+	return nil
+}
+
+// Checks if a failed download may succeed when retried.
+func isRetryableDownloadError(err error) bool {
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		// Network errors
+		return !errors.Is(err, context.Canceled)
+	}
+	return statusErr.statusCode >= http.StatusInternalServerError ||
+		statusErr.statusCode == http.StatusTooManyRequests ||
+		statusErr.statusCode == http.StatusRequestTimeout
+}
+
+// Downloads the file at the URL to the given destination, with a single GET request.
+// The host's credentials are added to the request, and are removed if redirected to another host.
+func downloadFile(ctx context.Context, rawUrl string, creds credentials, destinationFilePath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	authHeaders, err := creds.authorizeRequest(req)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		CheckRedirect: func(redirectReq *http.Request, via []*http.Request) error {
+			if len(via) >= maxDownloadRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxDownloadRedirects)
+			}
+			if redirectReq.URL.Host != req.URL.Host {
+				for _, header := range authHeaders {
+					redirectReq.Header.Del(header)
+				}
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error includes the URL, which may contain credentials
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactUrl(urlErr.URL)
+		}
+		return fmt.Errorf("failed HTTP GET request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &httpStatusError{statusCode: resp.StatusCode}
+	}
+
+	dstFile, err := os.Create(destinationFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer dstFile.Close()
+
+	_, err = io.Copy(dstFile, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed when downloading file: %w", err)
+	}
+	return nil
+}
+
+// Checks the file's SHA-256 hash matches the expected one, in hex.
+// Does nothing if the expected hash is empty.
+func verifyChecksum(filePath string, expectedHash string) error {
+	if expectedHash == "" {
+		return nil
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	actualHash := hex.EncodeToString(h.Sum(nil))
+	if actualHash != expectedHash {
+		return fmt.Errorf("%w: expected sha256 %s, got %s", ErrChecksumMismatch, expectedHash, actualHash)
+	}
+	return nil
+}
+
+// Returns the path where the file downloaded from the URL is cached.
+func downloadCachePath(rawUrl string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	urlHash := sha256.Sum256([]byte(rawUrl))
+	return filepath.Join(cacheDir, "avs-devnet", "downloads", hex.EncodeToString(urlHash[:])), nil
+}
+
+// Copies a cached download to the given destination.
+func copyFromCache(cachePath string, destinationFilePath string) error {
+	err := fileCopy(cachePath, destinationFilePath)
+	if err != nil {
+		return fmt.Errorf("failed when copying cached file: %w", err)
+	}
+	return nil
+}
+
+// Stores a copy of the downloaded file in the cache, replacing any previous one.
+// Errors are ignored, since the cache is optional.
+func storeDownloadCache(downloadedFilePath string, cachePath string) {
+	err := os.MkdirAll(filepath.Dir(cachePath), 0700)
+	if err != nil {
+		return
+	}
+	// Write to a temp file first, so the cache entry is never partially written
+	tempPath := cachePath + ".tmp"
+	err = fileCopy(downloadedFilePath, tempPath)
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return
+	}
+	_ = os.Rename(tempPath, cachePath)
+}
+
+// Checks if the path contains glob metacharacters.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Checks if the static file is a local glob pattern.
+// Remote URLs are never treated as patterns, since they may contain metacharacters in their query.
+func isLocalGlob(rawUrl string) bool {
+	srcUrl, err := url.Parse(rawUrl)
+	return (err != nil || isLocalUrl(srcUrl.Scheme)) && isGlobPattern(rawUrl)
+}
+
+// Copies the files matching the glob pattern into the destination dir.
+// Files are stored relative to the pattern's longest dir without metacharacters.
+// Metacharacters don't match across dirs, except for `**`, which matches any number of dirs.
+func copyGlobMatches(absPattern string, dstDir string) error {
+	baseDir, relPattern := splitGlobPattern(absPattern)
+	patternParts := strings.Split(relPattern, "/")
+	for _, part := range patternParts {
+		if _, err := path.Match(part, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", absPattern, err)
+		}
+	}
+	numMatches := 0
+	err := fsutil.CopyDir(baseDir, dstDir, func(relPath string, isDir bool) bool {
+		// Dirs are walked only if they may contain matches
+		matches := matchGlobParts(patternParts, strings.Split(relPath, "/"), isDir)
+		if matches && !isDir {
+			numMatches += 1
+		}
+		return matches
+	})
+	if err != nil {
+		return err
+	}
+	if numMatches == 0 {
+		return fmt.Errorf("pattern '%s' didn't match any files", absPattern)
+	}
+	return nil
+}
+
+// Checks if the path matches the glob pattern, both split by dir.
+// If `isDir`, checks instead if files inside the dir may match it.
+func matchGlobParts(patternParts []string, pathParts []string, isDir bool) bool {
+	if len(pathParts) == 0 {
+		if isDir {
+			return len(patternParts) > 0
+		}
+		return !slices.ContainsFunc(patternParts, func(part string) bool { return part != "**" })
+	}
+	if len(patternParts) == 0 {
+		return false
+	}
+	if patternParts[0] == "**" {
+		// Matches no dirs, or one more
+		return matchGlobParts(patternParts[1:], pathParts, isDir) || matchGlobParts(patternParts, pathParts[1:], isDir)
+	}
+	matches, _ := path.Match(patternParts[0], pathParts[0])
+	return matches && matchGlobParts(patternParts[1:], pathParts[1:], isDir)
+}
+
+// Splits the glob pattern into its longest dir without metacharacters, and the rest of the pattern.
+func splitGlobPattern(pattern string) (string, string) {
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, part := range parts {
+		if isGlobPattern(part) {
+			return filepath.FromSlash(strings.Join(parts[:i], "/")), strings.Join(parts[i:], "/")
+		}
+	}
+	return filepath.Dir(pattern), filepath.Base(pattern)
+}
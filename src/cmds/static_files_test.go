@@ -0,0 +1,260 @@
+package cmds_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/Layr-Labs/avs-devnet/src/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartExtractsStaticArchive(t *testing.T) {
+	t.Parallel()
+	archive := tarGzWith(t,
+		archiveEntry{name: "abi/Contract.json", contents: "{}"},
+		archiveEntry{name: "README.md", contents: "docs"},
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+
+	devnetConfig, err := config.Unmarshal([]byte(`
+artifacts:
+  bundle:
+    files:
+      contracts:
+        static_file: ` + server.URL + `/bundle.tar.gz
+        extract: true
+`))
+	require.NoError(t, err)
+	fake := backendtest.NewFake()
+	opts := fakeStartOptions(fake)
+	opts.DevnetConfig = devnetConfig
+	opts.WorkingDir = t.TempDir()
+
+	err = cmds.Start(context.Background(), opts)
+	require.NoError(t, err)
+	files, err := fake.Enclave("devnet").InspectArtifact(context.Background(), "bundle")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []backend.ArtifactFile{
+		{Path: "contracts/abi/Contract.json", TextPreview: "{}"},
+		{Path: "contracts/README.md", TextPreview: "docs"},
+	}, files)
+}
+
+func TestIsRetryableDownloadError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "server error", err: cmds.NewHttpStatusError(http.StatusInternalServerError), retryable: true},
+		{name: "bad gateway", err: cmds.NewHttpStatusError(http.StatusBadGateway), retryable: true},
+		{name: "rate limited", err: cmds.NewHttpStatusError(http.StatusTooManyRequests), retryable: true},
+		{name: "request timeout", err: cmds.NewHttpStatusError(http.StatusRequestTimeout), retryable: true},
+		{name: "not found", err: cmds.NewHttpStatusError(http.StatusNotFound)},
+		{name: "unauthorized", err: cmds.NewHttpStatusError(http.StatusUnauthorized)},
+		{name: "network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, retryable: true},
+		{
+			name:      "wrapped status",
+			err:       fmt.Errorf("download: %w", cmds.NewHttpStatusError(http.StatusServiceUnavailable)),
+			retryable: true,
+		},
+		{name: "cancelled", err: fmt.Errorf("failed HTTP GET request: %w", context.Canceled)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.retryable, cmds.IsRetryableDownloadError(tc.err))
+		})
+	}
+}
+
+// Returns a server answering with the given status codes in order, and the number of requests it received.
+// Requests after the last status code are answered with "contents".
+func newStatusServer(t *testing.T, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		request := int(requests.Add(1))
+		if request <= len(statusCodes) {
+			w.WriteHeader(statusCodes[request-1])
+			return
+		}
+		_, _ = w.Write([]byte("contents"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDownloadRetriesServerErrors(t *testing.T) {
+	t.Parallel()
+	server, requests := newStatusServer(t, http.StatusServiceUnavailable)
+	dstPath := filepath.Join(t.TempDir(), "file")
+
+	err := cmds.DownloadWithRetries(context.Background(), server.URL+"/file", cmds.Credentials{}, dstPath)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), requests.Load())
+	contents, err := os.ReadFile(dstPath)
+	require.NoError(t, err)
+	require.Equal(t, "contents", string(contents))
+}
+
+func TestDownloadDoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+	server, requests := newStatusServer(t, http.StatusNotFound)
+	dstPath := filepath.Join(t.TempDir(), "file")
+
+	err := cmds.DownloadWithRetries(context.Background(), server.URL+"/file", cmds.Credentials{}, dstPath)
+	require.ErrorContains(t, err, "status code: 404")
+	require.Equal(t, int32(1), requests.Load())
+	require.NoFileExists(t, dstPath)
+}
+
+func TestDownloadStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		// Cancelled while waiting to retry
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	err := cmds.DownloadWithRetries(ctx, server.URL+"/file", cmds.Credentials{}, filepath.Join(t.TempDir(), "file"))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, int32(1), requests.Load())
+}
+
+func TestDownloadFileWithCancelledContext(t *testing.T) {
+	t.Parallel()
+	server, requests := newStatusServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cmds.DownloadFile(ctx, server.URL+"/file", cmds.Credentials{}, filepath.Join(t.TempDir(), "file"))
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, cmds.IsRetryableDownloadError(err))
+	require.Equal(t, int32(0), requests.Load())
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("contents"), 0600))
+	hash := sha256.Sum256([]byte("contents"))
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+		err      error
+	}{
+		{name: "matching", path: filePath, expected: hex.EncodeToString(hash[:])},
+		{name: "no checksum", path: filePath, expected: ""},
+		{name: "mismatch", path: filePath, expected: strings.Repeat("0", 64), err: cmds.ErrChecksumMismatch},
+		{name: "missing file", path: filePath + "-missing", expected: strings.Repeat("0", 64), err: os.ErrNotExist},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := cmds.VerifyChecksum(tc.path, tc.expected)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestStartFailsOnChecksumMismatch(t *testing.T) {
+	t.Parallel()
+	server, _ := newStatusServer(t)
+	devnetConfig, err := config.Unmarshal([]byte(`
+artifacts:
+  bundle:
+    files:
+      file.txt:
+        static_file: ` + server.URL + `/file.txt
+        sha256: ` + strings.Repeat("0", 64) + `
+`))
+	require.NoError(t, err)
+	fake := backendtest.NewFake()
+	opts := fakeStartOptions(fake)
+	opts.DevnetConfig = devnetConfig
+	opts.WorkingDir = t.TempDir()
+
+	err = cmds.Start(context.Background(), opts)
+	require.ErrorIs(t, err, cmds.ErrChecksumMismatch)
+}
+
+func TestCopyGlobMatches(t *testing.T) {
+	t.Parallel()
+	srcDir := t.TempDir()
+	writeTestFiles(t, srcDir, map[string]string{
+		"keys/operator.key":          "operator",
+		"keys/nested/aggregator.key": "aggregator",
+		"docs/index.md":              "index",
+		"docs/guides/setup/anvil.md": "anvil",
+		"docs/guides/notes.txt":      "notes",
+	})
+	tests := []struct {
+		pattern  string
+		expected map[string]string
+		err      string
+	}{
+		{
+			// Files inside nested dirs don't match a single `*`
+			pattern:  "keys/*",
+			expected: map[string]string{"operator.key": "operator"},
+		},
+		{
+			pattern:  "keys/*/*.key",
+			expected: map[string]string{"nested/aggregator.key": "aggregator"},
+		},
+		{
+			pattern: "keys/**",
+			expected: map[string]string{
+				"operator.key":          "operator",
+				"nested/aggregator.key": "aggregator",
+			},
+		},
+		{
+			pattern: "docs/**/*.md",
+			expected: map[string]string{
+				"index.md":              "index",
+				"guides/setup/anvil.md": "anvil",
+			},
+		},
+		{pattern: "docs/*.txt", err: "didn't match any files"},
+		{pattern: "keys/[", err: "invalid pattern"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			t.Parallel()
+			dstDir := t.TempDir()
+			err := cmds.CopyGlobMatches(filepath.Join(srcDir, filepath.FromSlash(tc.pattern)), dstDir)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, regularFiles(t, dstDir))
+		})
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
 	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
-	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,26 +15,36 @@ var ErrEnclaveNotExists = errors.New("enclave doesn't exist")
 // Stops the devnet with the given context.
 func StopCmd(ctx *cli.Context) error {
 	devnetName := flags.DevnetNameFlag.Get(ctx)
+	b, err := backend.New(flags.BackendFlag.Get(ctx))
+	if err != nil {
+		return toExitError(err, devnetName)
+	}
 	fmt.Println("Stopping devnet...")
-	err := Stop(ctx.Context, devnetName)
+	err = StopWithBackend(ctx.Context, b, devnetName)
 	if errors.Is(err, ErrEnclaveNotExists) {
 		return cli.Exit("Failed to find '"+devnetName+"'. Maybe it's not running?", 1)
 	} else if err != nil {
-		return cli.Exit(err, 1)
+		return toExitError(err, devnetName)
 	}
 	fmt.Println("Devnet stopped!")
 	return nil
 }
 
+// Stops the devnet with the given name, running in Kurtosis.
 func Stop(ctx context.Context, devnetName string) error {
-	kurtosisCtx, err := kurtosis.InitKurtosisContext()
+	b, err := backend.NewKurtosis()
 	if err != nil {
 		return fmt.Errorf("failed to initialize kurtosis context: %w", err)
 	}
-	if !kurtosisCtx.EnclaveExists(ctx, devnetName) {
+	return StopWithBackend(ctx, b, devnetName)
+}
+
+// Stops the devnet with the given name, running in the given backend.
+func StopWithBackend(ctx context.Context, b backend.Backend, devnetName string) error {
+	if !b.EnclaveExists(ctx, devnetName) {
 		return ErrEnclaveNotExists
 	}
-	err = kurtosisCtx.DestroyEnclave(ctx, devnetName)
+	err := b.DestroyEnclave(ctx, devnetName)
 	if err != nil {
 		return fmt.Errorf("failed to destroy enclave: %w", err)
 	}
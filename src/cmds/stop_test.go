@@ -0,0 +1,30 @@
+package cmds_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/backend/backendtest"
+	"github.com/Layr-Labs/avs-devnet/src/cmds"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopDestroysEnclave(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+	fake.AddEnclave("devnet")
+
+	err := cmds.StopWithBackend(context.Background(), fake, "devnet")
+	require.NoError(t, err)
+	require.Nil(t, fake.Enclave("devnet"))
+	require.Equal(t, []string{"EnclaveExists", "DestroyEnclave"}, fake.Methods())
+}
+
+func TestStopMissingDevnet(t *testing.T) {
+	t.Parallel()
+	fake := backendtest.NewFake()
+
+	err := cmds.StopWithBackend(context.Background(), fake, "devnet")
+	require.ErrorIs(t, err, cmds.ErrEnclaveNotExists)
+	require.Equal(t, []string{"EnclaveExists"}, fake.Methods())
+}
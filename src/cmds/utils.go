@@ -6,6 +6,8 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/Layr-Labs/avs-devnet/src/backend"
+	"github.com/Layr-Labs/avs-devnet/src/cmds/flags"
 	"github.com/urfave/cli/v2"
 )
 
@@ -24,6 +26,20 @@ func parseConfigFileName(ctx *cli.Context) (string, error) {
 	return fileName, nil
 }
 
+// Returns the enclave of a running devnet, for use in CLI commands.
+// Errors are returned as exit errors.
+func openEnclave(ctx *cli.Context, devnetName string) (backend.Enclave, error) {
+	b, err := backend.New(flags.BackendFlag.Get(ctx))
+	if err != nil {
+		return nil, toExitError(err, devnetName)
+	}
+	enclave, err := b.GetEnclave(ctx.Context, devnetName)
+	if err != nil {
+		return nil, cli.Exit(err.Error()+"\n\nFailed to find devnet '"+devnetName+"'. Maybe it's not running?", 1)
+	}
+	return enclave, nil
+}
+
 // Checks if a file exists at the given path.
 func fileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
@@ -2,6 +2,8 @@ package config
 
 import (
 	_ "embed"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 
@@ -17,15 +19,49 @@ type DevnetConfig struct {
 	// Contains artifacts to generate
 	// The key is the artifact name
 	Artifacts map[string]Artifact `yaml:"artifacts"`
+	// Contains keys to generate
+	Keys []Key `yaml:"keys"`
 
+	// Chain to deploy to. Defaults to the ethereum-package.
+	Chain *ChainConfig `yaml:"chain"`
 	// Contains https://github.com/ethpandaops/ethereum-package configuration
 	EthereumPackage *EthereumPackageConfig `yaml:"ethereum_package"`
+	// Credentials for private repos and static files, by host
+	Auth []HostAuth `yaml:"auth"`
 
 	// non-exhaustive
 
 	raw []byte
 }
 
+// The chain the devnet runs on.
+type ChainConfig struct {
+	// Type of chain. One of ChainTypeEthereumPackage (default) or ChainTypeAnvil.
+	Type string `yaml:"type"`
+	// Only for Anvil. Seconds between blocks. If not set, a block is mined for each transaction.
+	BlockTime *float64 `yaml:"block_time"`
+	// Only for Anvil. Defaults to the ethereum-package's network ID.
+	ChainId *uint64 `yaml:"chain_id"`
+	// Only for Anvil. Number of dev accounts to generate.
+	Accounts *uint `yaml:"accounts"`
+}
+
+// Chain types.
+const (
+	// A full network (execution and consensus clients) started with the ethereum-package
+	ChainTypeEthereumPackage = "ethereum-package"
+	// A single Anvil node, which is faster to start and uses less resources
+	ChainTypeAnvil = "anvil"
+)
+
+// Returns the type of the config's chain, using ChainTypeEthereumPackage as default.
+func (c DevnetConfig) ChainType() string {
+	if c.Chain == nil || c.Chain.Type == "" {
+		return ChainTypeEthereumPackage
+	}
+	return c.Chain.Type
+}
+
 type EthereumPackageConfig struct {
 	Participants  []EthereumParticipant  `yaml:"participants"`
 	NetworkParams map[string]interface{} `yaml:"network_params"`
@@ -44,14 +80,37 @@ type Deployment struct {
 	Repo string `yaml:"repo"`
 	// The git ref to checkout
 	Ref string `yaml:"ref"`
+	// Prebuilt image to deploy from, used instead of building one from a remote repo
+	DeployerImage string `yaml:"deployer_image"`
 	// Path to the contracts dir
 	ContractsPath string `yaml:"contracts_path"`
 	// Path to the deployment script, relative to the contracts directory
 	Script string `yaml:"script"`
+	// How to upload the script of local repos. One of the UploadMode constants.
+	// Defaults to UploadModeAuto.
+	UploadMode string `yaml:"upload_mode"`
 
 	// non-exhaustive
 }
 
+// Upload modes for the scripts of local repos.
+const (
+	// Flatten the script if it's safe to do so, otherwise upload the whole project
+	UploadModeAuto = "auto"
+	// Upload only the flattened script and the foundry config
+	UploadModeFlatten = "flatten"
+	// Upload the whole foundry project
+	UploadModeProject = "project"
+)
+
+// Returns the upload mode of the deployment, using UploadModeAuto as default.
+func (d Deployment) GetUploadMode() string {
+	if d.UploadMode == "" {
+		return UploadModeAuto
+	}
+	return d.UploadMode
+}
+
 // Returns the path to the deployment script (i.e. `Script`) but without the trailing contract name
 // Example: "contracts/contracts.sol:Contract" -> "contracts/contracts.sol".
 func (d Deployment) GetScriptPath() string {
@@ -60,6 +119,40 @@ func (d Deployment) GetScriptPath() string {
 	return strings.TrimSuffix(scriptPath, ":")
 }
 
+// A key to generate, or an existing key to make available.
+type Key struct {
+	// Name for the key. Defaults to "key<index>".
+	Name string `yaml:"name"`
+	// Type of key. One of KeyTypeEcdsa (default) or KeyTypeBls.
+	Type string `yaml:"type"`
+	// Address of the key. Only for ECDSA keys.
+	Address string `yaml:"address"`
+	// Private key. If neither this nor Address are set, the key is generated.
+	PrivateKey string `yaml:"private_key"`
+}
+
+// Key types.
+const (
+	KeyTypeEcdsa = "ecdsa"
+	KeyTypeBls   = "bls"
+)
+
+// Returns the name of the key at the given index of the config's keys.
+func (k Key) GetName(index int) string {
+	if k.Name == "" {
+		return fmt.Sprintf("key%d", index)
+	}
+	return k.Name
+}
+
+// Returns the type of the key, using KeyTypeEcdsa as default.
+func (k Key) GetType() string {
+	if k.Type == "" {
+		return KeyTypeEcdsa
+	}
+	return k.Type
+}
+
 // A service to start.
 type Service struct {
 	// The service name
@@ -74,10 +167,45 @@ type Service struct {
 	// Optional. The build file to specify when building the docker image.
 	// Ignored unless BuildContext is set.
 	BuildFile *string `yaml:"build_file"`
+	// Optional. Glob patterns of the files used by BuildCmd, relative to the working directory.
+	// If set, the build is skipped when none of these files changed since the last build.
+	BuildInputs []string `yaml:"build_inputs"`
+	// Ports exposed by the service, by name
+	Ports map[string]Port `yaml:"ports"`
+	// Env vars to set. Values are scalars, and strings can contain templates.
+	Env map[string]interface{} `yaml:"env"`
+	// Command to run. Args can contain templates.
+	Cmd []interface{} `yaml:"cmd"`
+	// Artifacts to mount, by path inside the container
+	Input map[string]ArtifactNames `yaml:"input"`
 
 	// non-exhaustive
 }
 
+// A port exposed by a service.
+type Port struct {
+	Number              uint16 `yaml:"number"`
+	TransportProtocol   string `yaml:"transport_protocol"`
+	ApplicationProtocol string `yaml:"application_protocol"`
+	Wait                string `yaml:"wait"`
+}
+
+// A list of artifact names, which can also be written as a single name.
+type ArtifactNames []string
+
+func (n *ArtifactNames) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*n = ArtifactNames{value.Value}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
 // An artifact to generate.
 // The key is the file name, and the value is the file's definition.
 type Artifact struct {
@@ -88,14 +216,54 @@ type Artifact struct {
 }
 
 // The definition of an artifact file.
-// Must be either a static file or a template.
+// Must be either a static file, a static dir, or a template.
 type ArtifactFile struct {
-	// URL to a file to upload to the enclave
+	// URL to a file to upload to the enclave.
+	// For local files, it can also be a glob pattern, in which case the matches are stored in a dir.
 	StaticFile *string `yaml:"static_file"`
+	// Path to a local dir to upload to the enclave
+	StaticDir *string `yaml:"static_dir"`
+	// Extract the static file, which must be a .tar.gz or .zip archive, into a dir
+	Extract bool `yaml:"extract"`
+	// Expected SHA-256 hash of the static file, in hex.
+	// An optional 'sha256:' prefix is accepted.
+	Sha256 *string `yaml:"sha256"`
 	// Content of the file, with optional templates
 	Template *string `yaml:"template"`
 }
 
+// Checks if the file is taken from a static source.
+func (f ArtifactFile) IsStatic() bool {
+	return f.StaticFile != nil || f.StaticDir != nil
+}
+
+// Credentials used when accessing a host.
+// Secrets are never stored in the config, only the names of the env vars holding them.
+type HostAuth struct {
+	// Host the credentials are used for (e.g. github.com)
+	Host string `yaml:"host"`
+	// Name of the env var holding an access token.
+	// It's used when cloning repos over HTTPS, and as a bearer token when fetching static files.
+	TokenEnv string `yaml:"token_env"`
+	// Username used along with the token when cloning repos.
+	// Defaults to DefaultAuthUsername.
+	Username string `yaml:"username"`
+	// Headers sent when fetching static files.
+	// Values can reference env vars, like `$MY_TOKEN` or `${MY_TOKEN}`.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Username used with access tokens by default, accepted by GitHub.
+const DefaultAuthUsername = "x-access-token"
+
+// Returns the username used along with the token, using DefaultAuthUsername as default.
+func (a HostAuth) GetUsername() string {
+	if a.Username == "" {
+		return DefaultAuthUsername
+	}
+	return a.Username
+}
+
 // Loads a DevnetConfig from a file.
 func LoadFromPath(filePath string) (DevnetConfig, error) {
 	var config DevnetConfig
@@ -117,6 +285,132 @@ func Unmarshal(file []byte) (DevnetConfig, error) {
 	return config, nil
 }
 
+// Returns a copy of the config, with the deployer image of the named deployments set.
+// The serialized config is updated too, keeping the rest of its contents.
+func (c DevnetConfig) WithDeployerImages(imagesByDeployment map[string]string) (DevnetConfig, error) {
+	if len(imagesByDeployment) == 0 {
+		return c, nil
+	}
+	var root yaml.Node
+	err := yaml.Unmarshal(c.raw, &root)
+	if err != nil {
+		return c, err
+	}
+	if len(root.Content) == 0 {
+		return c, errors.New("config is empty")
+	}
+	deployments := mappingValue(root.Content[0], "deployments")
+	if deployments == nil || deployments.Kind != yaml.SequenceNode {
+		return c, errors.New("config has no deployments")
+	}
+	for _, deployment := range deployments.Content {
+		name := mappingValue(deployment, "name")
+		if name == nil {
+			continue
+		}
+		image, ok := imagesByDeployment[name.Value]
+		if !ok {
+			continue
+		}
+		imageNode := mappingValue(deployment, "deployer_image")
+		if imageNode == nil {
+			imageNode = &yaml.Node{}
+			deployment.Content = append(deployment.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "deployer_image"}, imageNode)
+		}
+		imageNode.SetString(image)
+	}
+	raw, err := yaml.Marshal(&root)
+	if err != nil {
+		return c, err
+	}
+	return Unmarshal(raw)
+}
+
+// Returns a copy of the config, with the chain's type set.
+// The serialized config is updated too, keeping the rest of its contents.
+func (c DevnetConfig) WithChainType(chainType string) (DevnetConfig, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal(c.raw, &root)
+	if err != nil {
+		return c, err
+	}
+	if len(root.Content) == 0 {
+		return c, errors.New("config is empty")
+	}
+	chain := mappingValue(root.Content[0], "chain")
+	if chain == nil {
+		// Append the section, so the rest of the file keeps its formatting
+		section := fmt.Sprintf("\n# Chain to deploy to\nchain:\n  type: %s\n", chainType)
+		raw := strings.TrimRight(string(c.raw), " \n") + "\n" + section
+		return Unmarshal([]byte(raw))
+	}
+	if chain.Kind != yaml.MappingNode {
+		return c, errors.New("config's chain isn't a mapping")
+	}
+	typeNode := mappingValue(chain, "type")
+	if typeNode == nil {
+		typeNode = &yaml.Node{}
+		chain.Content = append(chain.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "type"}, typeNode)
+	}
+	typeNode.SetString(chainType)
+	raw, err := yaml.Marshal(&root)
+	if err != nil {
+		return c, err
+	}
+	return Unmarshal(raw)
+}
+
+// Args passed to the Kurtosis package when starting from a snapshot.
+// They aren't part of the config file, and are only set by the CLI.
+type SnapshotArgs struct {
+	// Name of the artifact with the chain's state
+	ChainState string `yaml:"chain_state"`
+	// Keys and addresses generated by the devnet the snapshot was taken from
+	Data map[string]interface{} `yaml:"data"`
+}
+
+// Returns a copy of the config, with the args to start from a snapshot.
+// The serialized config is updated too, keeping the rest of its contents.
+func (c DevnetConfig) WithSnapshot(args SnapshotArgs) (DevnetConfig, error) {
+	var root yaml.Node
+	err := yaml.Unmarshal(c.raw, &root)
+	if err != nil {
+		return c, err
+	}
+	if len(root.Content) == 0 {
+		return c, errors.New("config is empty")
+	}
+	var argsNode yaml.Node
+	err = argsNode.Encode(args)
+	if err != nil {
+		return c, err
+	}
+	mapping := root.Content[0]
+	if snapshotNode := mappingValue(mapping, "snapshot"); snapshotNode != nil {
+		*snapshotNode = argsNode
+	} else {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "snapshot"}, &argsNode)
+	}
+	raw, err := yaml.Marshal(&root)
+	if err != nil {
+		return c, err
+	}
+	return Unmarshal(raw)
+}
+
+// Returns the value of the key in the YAML mapping, or nil if it doesn't exist.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // Serializes the config.
 // If read from a file, the serialized config will be the same as the file's content.
 func (c DevnetConfig) Marshal() []byte {
@@ -134,3 +428,12 @@ func DefaultConfig() DevnetConfig {
 func DefaultConfigStr() string {
 	return string(defaultConfig)
 }
+
+// Returns the default config, but running on an Anvil chain.
+func FastConfigStr() string {
+	cfg, err := DefaultConfig().WithChainType(ChainTypeAnvil)
+	if err != nil {
+		return DefaultConfigStr()
+	}
+	return string(cfg.Marshal())
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Layr-Labs/avs-devnet/src/config"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed default_config.yaml
@@ -57,3 +58,89 @@ func TestUnmarshalAndMarshalReturnsSameString(t *testing.T) {
 		require.Equal(t, rawCfg, cfg.Marshal(), "Unmarshalled and marshalled config don't match")
 	})
 }
+
+func TestWithDeployerImages(t *testing.T) {
+	rawCfg := []byte(`# Some comment
+deployments:
+  - name: first
+    repo: "https://github.com/some-org/first.git"
+  - name: second
+    repo: "https://github.com/some-org/second.git"
+    deployer_image: old-image
+  - name: third
+    repo: "https://github.com/some-org/third.git"
+`)
+	cfg, err := config.Unmarshal(rawCfg)
+	require.NoError(t, err, "Failed to unmarshal config")
+
+	newCfg, err := cfg.WithDeployerImages(map[string]string{"first": "first-image", "second": "second-image"})
+	require.NoError(t, err, "Failed to set deployer images")
+
+	require.Equal(t, "first-image", newCfg.Deployments[0].DeployerImage)
+	require.Equal(t, "second-image", newCfg.Deployments[1].DeployerImage)
+	require.Equal(t, "", newCfg.Deployments[2].DeployerImage)
+	require.Contains(t, string(newCfg.Marshal()), "# Some comment")
+
+	// The serialized config is updated too
+	reloadedCfg, err := config.Unmarshal(newCfg.Marshal())
+	require.NoError(t, err, "Failed to unmarshal updated config")
+	require.Equal(t, newCfg.Deployments, reloadedCfg.Deployments)
+	// The original config isn't modified
+	require.Equal(t, rawCfg, cfg.Marshal())
+}
+
+func TestWithSnapshot(t *testing.T) {
+	rawCfg := []byte(`# Some comment
+chain:
+  type: anvil
+`)
+	cfg, err := config.Unmarshal(rawCfg)
+	require.NoError(t, err, "Failed to unmarshal config")
+
+	args := config.SnapshotArgs{
+		ChainState: "chain_state",
+		Data:       map[string]interface{}{"addresses": map[string]interface{}{"contract": "0x1234"}},
+	}
+	newCfg, err := cfg.WithSnapshot(args)
+	require.NoError(t, err, "Failed to set snapshot args")
+	require.Equal(t, config.ChainTypeAnvil, newCfg.ChainType())
+	require.Contains(t, string(newCfg.Marshal()), "# Some comment")
+
+	var parsed struct {
+		Snapshot config.SnapshotArgs `yaml:"snapshot"`
+	}
+	require.NoError(t, yaml.Unmarshal(newCfg.Marshal(), &parsed))
+	require.Equal(t, args, parsed.Snapshot)
+	// The original config isn't modified
+	require.Equal(t, rawCfg, cfg.Marshal())
+}
+
+func TestWithChainType(t *testing.T) {
+	tests := []struct {
+		name   string
+		rawCfg string
+	}{
+		{name: "no chain", rawCfg: "# Some comment\nkeys:\n  - name: key\n    type: ecdsa\n"},
+		{name: "no chain type", rawCfg: "# Some comment\nchain:\n  block_time: 2\n"},
+		{name: "other chain type", rawCfg: "# Some comment\nchain:\n  type: ethereum-package\n  block_time: 2\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := config.Unmarshal([]byte(tc.rawCfg))
+			require.NoError(t, err, "Failed to unmarshal config")
+
+			newCfg, err := cfg.WithChainType(config.ChainTypeAnvil)
+			require.NoError(t, err, "Failed to set chain type")
+			require.Equal(t, config.ChainTypeAnvil, newCfg.ChainType())
+			require.Equal(t, cfg.Keys, newCfg.Keys)
+			require.Contains(t, string(newCfg.Marshal()), "# Some comment")
+
+			// The serialized config is updated too
+			reloadedCfg, err := config.Unmarshal(newCfg.Marshal())
+			require.NoError(t, err, "Failed to unmarshal updated config")
+			require.Equal(t, newCfg.Chain, reloadedCfg.Chain)
+			// The original config isn't modified
+			require.Equal(t, tc.rawCfg, string(cfg.Marshal()))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Error types returned by the devnet.
+// They can be matched with `errors.As` to tell apart the different failure categories.
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The Kurtosis package failed validation.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "validation error: " + e.Message
+}
+
+// The Kurtosis package failed interpretation.
+// This usually means the configuration file is invalid.
+type InterpretationError struct {
+	Message string
+}
+
+func (e *InterpretationError) Error() string {
+	return "interpretation error: " + e.Message
+}
+
+// The Kurtosis package failed during execution.
+type ExecutionError struct {
+	Message string
+}
+
+func (e *ExecutionError) Error() string {
+	return "execution error: " + e.Message
+}
+
+// A dependency needed by the devnet is missing or not running.
+type DependencyMissingError struct {
+	// Name of the missing dependency (e.g. "docker")
+	Dependency string
+	// Optional. The underlying error
+	Err error
+}
+
+func (e *DependencyMissingError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("dependency '%s' is missing", e.Dependency)
+	}
+	return fmt.Sprintf("dependency '%s' is missing: %s", e.Dependency, e.Err)
+}
+
+func (e *DependencyMissingError) Unwrap() error {
+	return e.Err
+}
+
+// A devnet with the same name is already running.
+type DevnetExistsError struct {
+	// Name of the devnet
+	Name string
+}
+
+func (e *DevnetExistsError) Error() string {
+	return fmt.Sprintf("devnet '%s' already running", e.Name)
+}
+
+// The Kurtosis engine's version is incompatible with the one expected by the Kurtosis SDK.
+type EngineVersionMismatchError struct {
+	// Version of the running engine
+	EngineVersion string
+	// Version expected by the SDK
+	ExpectedVersion string
+}
+
+func (e *EngineVersionMismatchError) Error() string {
+	return fmt.Sprintf(
+		"kurtosis engine version '%s' is incompatible with the expected version '%s'",
+		e.EngineVersion,
+		e.ExpectedVersion,
+	)
+}
+
+// JSON-RPC error code returned for unknown methods.
+const MethodNotFoundCode = -32601
+
+// Returns whether the error is a JSON-RPC error for an unknown method.
+// This usually means the execution client doesn't support it, like reth with Anvil's methods.
+func IsMethodNotFound(err error) bool {
+	var rpcErr interface{ ErrorCode() int }
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == MethodNotFoundCode
+}
@@ -0,0 +1,60 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extracts the regular files and dirs of a gzipped tarball into the destination dir.
+// Entries outside the destination dir are rejected.
+func ExtractTarGz(contents io.Reader, dstDir string) error {
+	err := os.MkdirAll(dstDir, 0700)
+	if err != nil {
+		return fmt.Errorf("output dir creation failed: %w", err)
+	}
+	gzipReader, err := gzip.NewReader(contents)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, nextErr := tarReader.Next()
+		if errors.Is(nextErr, io.EOF) {
+			return nil
+		}
+		if nextErr != nil {
+			return nextErr
+		}
+		dstPath, err := ArchiveEntryPath(dstDir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dstPath, 0700)
+		case tar.TypeReg:
+			err = WriteFile(dstPath, tarReader, header.FileInfo().Mode())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Returns the path of the archive entry inside the destination dir.
+// Fails if the entry would be extracted outside of it.
+func ArchiveEntryPath(dstDir string, entryName string) (string, error) {
+	dstDir = filepath.Clean(dstDir)
+	dstPath := filepath.Join(dstDir, filepath.FromSlash(entryName))
+	if dstPath != dstDir && !strings.HasPrefix(dstPath, dstDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry '%s' is outside the destination dir", entryName)
+	}
+	return dstPath, nil
+}
@@ -0,0 +1,109 @@
+package fsutil_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTarGz(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		headers  []tar.Header
+		expected map[string]string
+		err      string
+	}{
+		{
+			name: "files and dirs",
+			headers: []tar.Header{
+				{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "dir/file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+			expected: map[string]string{"dir/file.txt": "dir/file.txt"},
+		},
+		{
+			name: "symlinks are skipped",
+			headers: []tar.Header{
+				{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+				{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+			expected: map[string]string{"file.txt": "file.txt"},
+		},
+		{
+			name:    "outside the destination dir",
+			headers: []tar.Header{{Name: "../escaped.txt", Typeflag: tar.TypeReg, Mode: 0644}},
+			err:     "is outside the destination dir",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			// Regular files contain their own name
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			tarWriter := tar.NewWriter(gzipWriter)
+			for _, header := range tc.headers {
+				var contents []byte
+				if header.Typeflag == tar.TypeReg {
+					contents = []byte(header.Name)
+					header.Size = int64(len(contents))
+				}
+				require.NoError(t, tarWriter.WriteHeader(&header))
+				_, err := tarWriter.Write(contents)
+				require.NoError(t, err)
+			}
+			require.NoError(t, tarWriter.Close())
+			require.NoError(t, gzipWriter.Close())
+
+			tempDir := t.TempDir()
+			dstDir := filepath.Join(tempDir, "dst")
+			err := fsutil.ExtractTarGz(&buf, dstDir)
+			require.NoFileExists(t, filepath.Join(tempDir, "escaped.txt"))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, readFiles(t, dstDir))
+		})
+	}
+}
+
+func TestArchiveEntryPath(t *testing.T) {
+	t.Parallel()
+	dstDir := filepath.Join(t.TempDir(), "dst")
+	tests := []struct {
+		entry    string
+		expected string
+		err      bool
+	}{
+		{entry: "file.txt", expected: filepath.Join(dstDir, "file.txt")},
+		{entry: "dir/file.txt", expected: filepath.Join(dstDir, "dir", "file.txt")},
+		{entry: "./", expected: dstDir},
+		{entry: "dir/../file.txt", expected: filepath.Join(dstDir, "file.txt")},
+		{entry: "/abs/file.txt", expected: filepath.Join(dstDir, "abs", "file.txt")},
+		{entry: "..", err: true},
+		{entry: "../file.txt", err: true},
+		{entry: "dir/../../file.txt", err: true},
+		// Shares the destination dir's name as prefix
+		{entry: "../dst-sibling/file.txt", err: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.entry, func(t *testing.T) {
+			t.Parallel()
+			dstPath, err := fsutil.ArchiveEntryPath(dstDir, tc.entry)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, dstPath)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// File system helpers shared by the CLI's packages.
+package fsutil
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Copies the files inside `srcDir` into `dstDir`, preserving their relative structure and permissions.
+// Empty dirs aren't copied.
+// `include` is called with each entry's slash-separated path relative to `srcDir`.
+// Files for which it returns false are skipped, and so are the contents of dirs.
+// If nil, everything is copied.
+// Symlinks to files are copied as regular files, and symlinks to dirs are skipped.
+func CopyDir(srcDir string, dstDir string, include func(relPath string, isDir bool) bool) error {
+	err := os.MkdirAll(dstDir, 0700)
+	if err != nil {
+		return fmt.Errorf("output dir creation failed: %w", err)
+	}
+	return filepath.WalkDir(srcDir, func(filePath string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		relPath, err := filepath.Rel(srcDir, filePath)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if include != nil && !include(filepath.ToSlash(relPath), entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			// Dirs are created when copying their files
+			return nil
+		}
+		// Follows symlinks
+		info, err := os.Stat(filePath)
+		if err != nil || info.IsDir() {
+			return nil //nolint:nilerr // broken links and links to dirs are skipped
+		}
+		return CopyFile(filePath, filepath.Join(dstDir, relPath), info.Mode())
+	})
+}
+
+// Copies the file at `src` to `dst`, with the given permissions.
+// The destination's parent dirs are created if needed.
+func CopyFile(src string, dst string, mode fs.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer srcFile.Close()
+	return WriteFile(dst, srcFile, mode)
+}
+
+// Writes the contents to the given path, creating its parent dirs.
+// The file is always readable and writable by its owner.
+func WriteFile(dstPath string, contents io.Reader, mode fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(dstPath), 0700)
+	if err != nil {
+		return fmt.Errorf("output dir creation failed: %w", err)
+	}
+	file, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+	_, err = io.Copy(file, contents)
+	if err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return nil
+}
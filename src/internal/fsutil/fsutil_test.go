@@ -0,0 +1,99 @@
+package fsutil_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/internal/fsutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyDir(t *testing.T) {
+	t.Parallel()
+	srcDir := t.TempDir()
+	writeFiles(t, srcDir, map[string]string{
+		"a.txt":          "a",
+		"dir/b.txt":      "b",
+		"skipped/c.txt":  "c",
+		"dir/ignored.md": "ignored",
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(srcDir, "empty"), 0700))
+	require.NoError(t, os.Symlink(filepath.Join(srcDir, "a.txt"), filepath.Join(srcDir, "link.txt")))
+	require.NoError(t, os.Symlink(filepath.Join(srcDir, "dir"), filepath.Join(srcDir, "dirlink")))
+
+	dstDir := filepath.Join(t.TempDir(), "dst")
+	err := fsutil.CopyDir(srcDir, dstDir, func(relPath string, isDir bool) bool {
+		if isDir {
+			return relPath != "skipped"
+		}
+		return !strings.HasSuffix(relPath, ".md")
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"a.txt":     "a",
+		"dir/b.txt": "b",
+		// Links to files are copied as regular files
+		"link.txt": "a",
+	}, readFiles(t, dstDir))
+	require.NoDirExists(t, filepath.Join(dstDir, "empty"))
+}
+
+func TestCopyDirWithoutFilter(t *testing.T) {
+	t.Parallel()
+	srcDir := t.TempDir()
+	files := map[string]string{"a.txt": "a", "dir/nested/b.txt": "b"}
+	writeFiles(t, srcDir, files)
+	require.NoError(t, os.Chmod(filepath.Join(srcDir, "a.txt"), 0755))
+
+	dstDir := t.TempDir()
+	require.NoError(t, fsutil.CopyDir(srcDir, dstDir, nil))
+	require.Equal(t, files, readFiles(t, dstDir))
+	info, err := os.Stat(filepath.Join(dstDir, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+}
+
+func TestWriteFileCreatesParentDirs(t *testing.T) {
+	t.Parallel()
+	dstPath := filepath.Join(t.TempDir(), "a", "b", "file.txt")
+	// Files are always writable by their owner
+	require.NoError(t, fsutil.WriteFile(dstPath, strings.NewReader("contents"), 0400))
+	contents, err := os.ReadFile(dstPath)
+	require.NoError(t, err)
+	require.Equal(t, "contents", string(contents))
+	info, err := os.Stat(dstPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// Writes the files, by slash-separated path relative to `dir`.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for relPath, contents := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(relPath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		require.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+	}
+}
+
+// Returns the regular files inside `dir`, by slash-separated path.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(relPath)] = string(contents)
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
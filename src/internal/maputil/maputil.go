@@ -0,0 +1,14 @@
+// Map helpers shared by the CLI's packages.
+package maputil
+
+import "sort"
+
+// Returns the sorted keys of a map.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,245 @@
+package kurtosis
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	kapi "github.com/kurtosis-tech/kurtosis/api/golang/engine/kurtosis_engine_rpc_api_bindings"
+	"github.com/kurtosis-tech/kurtosis/api/golang/engine/lib/kurtosis_context"
+	"github.com/kurtosis-tech/kurtosis/api/golang/kurtosis_version"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var ErrEngineNotRunning = errors.New("kurtosis engine is not running")
+
+// Version of the Kurtosis engine expected by the SDK.
+const SdkVersion = kurtosis_version.KurtosisVersion
+
+// Returns the version of the local Kurtosis engine, via the engine's API.
+// Fails with ErrEngineNotRunning if the engine can't be reached.
+func GetEngineVersion(ctx context.Context) (string, error) {
+	engineUrl := fmt.Sprintf("127.0.0.1:%d", kurtosis_context.DefaultGrpcEngineServerPortNum)
+	conn, err := grpc.NewClient(engineUrl, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the Kurtosis engine: %w", err)
+	}
+	defer conn.Close()
+	info, err := kapi.NewEngineServiceClient(conn).GetEngineInfo(ctx, &emptypb.Empty{})
+	if status.Code(err) == codes.Unavailable {
+		return "", ErrEngineNotRunning
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get Kurtosis engine info: %w", err)
+	}
+	return info.GetEngineVersion(), nil
+}
+
+// Checks the engine's version is compatible with the SDK's.
+// Like the SDK, only the major and minor versions need to match.
+func CheckEngineVersion(engineVersion string) error {
+	if majorMinor(engineVersion) != majorMinor(SdkVersion) {
+		return &errdefs.EngineVersionMismatchError{EngineVersion: engineVersion, ExpectedVersion: SdkVersion}
+	}
+	return nil
+}
+
+// Labels set by Kurtosis on the engine's containers.
+const (
+	appIdLabel         = "com.kurtosistech.app-id=kurtosis"
+	engineTypeLabel    = "com.kurtosistech.container-type=kurtosis-engine"
+	engineGuidLabel    = "com.kurtosistech.guid"
+	enginePortsLabel   = "com.kurtosistech.ports"
+	engineStopTimeoutS = "30"
+)
+
+const (
+	engineImage = "kurtosistech/engine"
+	// Ports of the engine's enclave manager UI and REST API, published along with the gRPC one
+	engineUiPort      = 9711
+	engineRestApiPort = 9779
+	dockerSocketPath  = "/var/run/docker.sock"
+	// How long to wait for a started engine's API to be reachable
+	engineStartTimeout      = 60 * time.Second
+	engineStartPollInterval = 500 * time.Millisecond
+)
+
+// Arguments passed to the engine server, serialized in its SERIALIZED_ARGS env var.
+// Mirrors the engine launcher's EngineServerArgs, which isn't published as part of the Go SDK.
+type engineServerArgs struct {
+	GrpcListenPortNum           uint16 `json:"grpcListenPortNum"`
+	LogLevelStr                 string `json:"logLevelStr"`
+	ImageVersionTag             string `json:"imageVersionTag"`
+	MetricsUserID               string `json:"metricsUserID"`
+	DidUserAcceptSendingMetrics bool   `json:"didUserAcceptSendingMetrics"`
+	IsCI                        bool   `json:"is_ci"`
+	CloudUserID                 string `json:"cloud_user_id"`
+	CloudInstanceID             string `json:"cloud_instance_id"`
+	KurtosisBackendType         string `json:"kurtosisBackendType"`
+	KurtosisBackendConfig       any    `json:"kurtosisBackendConfig"`
+	OnBastionHost               bool   `json:"onBastionHost"`
+	PoolSize                    uint8  `json:"poolSize"`
+	EnclaveEnvVars              string `json:"enclaveEnvVars"`
+	RestAPIPortAddr             uint16 `json:"restAPIPortAddr"`
+	Domain                      string `json:"domain"`
+	LogRetentionPeriod          string `json:"logRetentionPeriod"`
+}
+
+// Starts the local Kurtosis engine with the SDK's version, and waits for its API to be reachable.
+// Like StopEngine, the engine's containers are managed through Docker, so the Kurtosis CLI isn't needed.
+// A stopped engine container with the SDK's version is started again, otherwise a new one is created.
+func StartEngine(ctx context.Context) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	image := engineImage + ":" + SdkVersion
+	output, err := runCommand(ctx, "docker", "ps", "--all", "--quiet",
+		"--filter", "label="+appIdLabel, "--filter", "label="+engineTypeLabel, "--filter", "ancestor="+image)
+	if err != nil {
+		return err
+	}
+	// The most recently created container is listed first
+	containerId, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if containerId != "" {
+		_, err = runCommand(ctx, "docker", "start", containerId)
+	} else {
+		containerId, err = createEngine(ctx, image)
+	}
+	if err != nil {
+		return err
+	}
+	return waitForEngine(ctx, containerId)
+}
+
+// Creates and starts a new engine container from the given image, returning its ID.
+// The container is set up like the Kurtosis CLI does.
+func createEngine(ctx context.Context, image string) (string, error) {
+	guid, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	metricsUserId, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	serializedArgs, err := json.Marshal(engineServerArgs{
+		GrpcListenPortNum:   kurtosis_context.DefaultGrpcEngineServerPortNum,
+		LogLevelStr:         "info",
+		ImageVersionTag:     SdkVersion,
+		MetricsUserID:       metricsUserId,
+		KurtosisBackendType: "docker",
+		RestAPIPortAddr:     engineRestApiPort,
+		LogRetentionPeriod:  "168h",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize the engine's args: %w", err)
+	}
+	grpcPort := kurtosis_context.DefaultGrpcEngineServerPortNum
+	output, err := runCommand(ctx, "docker", "run", "--detach",
+		"--name", "kurtosis-engine--"+guid,
+		"--label", appIdLabel,
+		"--label", engineTypeLabel,
+		"--label", engineGuidLabel+"="+guid,
+		"--label", fmt.Sprintf("%s=grpc:%d/TCP", enginePortsLabel, grpcPort),
+		"--publish", fmt.Sprintf("%d:%d", grpcPort, grpcPort),
+		"--publish", fmt.Sprintf("%d:%d", engineUiPort, engineUiPort),
+		"--publish", fmt.Sprintf("%d:%d", engineRestApiPort, engineRestApiPort),
+		"--volume", dockerSocketPath+":"+dockerSocketPath,
+		"--restart", "on-failure",
+		"--env", "SERIALIZED_ARGS="+string(serializedArgs),
+		image,
+	)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// Waits until the engine's API is reachable, or `engineStartTimeout` passes.
+func waitForEngine(ctx context.Context, containerId string) error {
+	timeout := time.After(engineStartTimeout)
+	for {
+		_, err := GetEngineVersion(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf(
+				"the Kurtosis engine didn't start within %s, check its logs with `docker logs %s`: %w",
+				engineStartTimeout, containerId, err,
+			)
+		case <-time.After(engineStartPollInterval):
+		}
+	}
+}
+
+// Returns a random hex string of `numBytes` bytes.
+func randomHex(numBytes int) (string, error) {
+	bytes := make([]byte, numBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// Stops the local Kurtosis engine. Running devnets are kept.
+// The engine's containers are stopped through Docker, so the Kurtosis CLI isn't needed.
+func StopEngine(ctx context.Context) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return &errdefs.DependencyMissingError{Dependency: "docker", Err: err}
+	}
+	output, err := runCommand(ctx, "docker", "ps", "--quiet", "--filter", "label="+appIdLabel, "--filter", "label="+engineTypeLabel)
+	if err != nil {
+		return err
+	}
+	containerIds := strings.Fields(string(output))
+	if len(containerIds) == 0 {
+		return ErrEngineNotRunning
+	}
+	args := append([]string{"stop", "--time", engineStopTimeoutS}, containerIds...)
+	_, err = runCommand(ctx, "docker", args...)
+	return err
+}
+
+// Restarts the local Kurtosis engine with the SDK's version.
+// Used to fix version mismatches.
+func RestartEngine(ctx context.Context) error {
+	err := StopEngine(ctx)
+	if err != nil && !errors.Is(err, ErrEngineNotRunning) {
+		return err
+	}
+	return StartEngine(ctx)
+}
+
+// Runs the command, returning its stdout.
+// If it fails, the error includes the command's stderr.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("`%s %s` failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// Returns the "X.Y" prefix of a "X.Y.Z" version.
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	return strings.Join(parts[:min(2, len(parts))], ".")
+}
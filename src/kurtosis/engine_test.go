@@ -0,0 +1,146 @@
+package kurtosis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEngineVersion(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, kurtosis.CheckEngineVersion(kurtosis.SdkVersion))
+	require.NoError(t, kurtosis.CheckEngineVersion("1.4.99"))
+
+	for _, version := range []string{"1.3.4", "2.4.4", "1", ""} {
+		err := kurtosis.CheckEngineVersion(version)
+		var mismatchErr *errdefs.EngineVersionMismatchError
+		require.ErrorAs(t, err, &mismatchErr, version)
+		require.Equal(t, version, mismatchErr.EngineVersion)
+		require.Equal(t, kurtosis.SdkVersion, mismatchErr.ExpectedVersion)
+	}
+}
+
+// Installs a fake command in a new dir prepended to PATH.
+// It logs its args to the returned file, prints `stdout` and `stderr`, and exits with `exitCode`.
+func fakeCommand(t *testing.T, name string, stdout string, stderr string, exitCode int) string {
+	t.Helper()
+	binDir := t.TempDir()
+	logPath := filepath.Join(binDir, name+".log")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\nprintf '%%s' '%s'\nprintf '%%s' '%s' >&2\nexit %d\n",
+		logPath, stdout, stderr, exitCode)
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, name), []byte(script), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logPath
+}
+
+// Returns the lines logged by a fake command.
+func loggedCalls(t *testing.T, logPath string) []string {
+	t.Helper()
+	contents, err := os.ReadFile(logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(contents)), "\n")
+}
+
+func TestStopEngine(t *testing.T) {
+	logPath := fakeCommand(t, "docker", "abc123\ndef456", "", 0)
+
+	err := kurtosis.StopEngine(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"ps --quiet --filter label=com.kurtosistech.app-id=kurtosis --filter label=com.kurtosistech.container-type=kurtosis-engine",
+		"stop --time 30 abc123 def456",
+	}, loggedCalls(t, logPath))
+}
+
+func TestStopEngineNotRunning(t *testing.T) {
+	fakeCommand(t, "docker", "", "", 0)
+
+	err := kurtosis.StopEngine(context.Background())
+	require.ErrorIs(t, err, kurtosis.ErrEngineNotRunning)
+}
+
+func TestEngineCommandErrorsIncludeStderr(t *testing.T) {
+	fakeCommand(t, "docker", "", "permission denied while trying to connect to the Docker daemon socket", 1)
+
+	err := kurtosis.StopEngine(context.Background())
+	require.ErrorContains(t, err, "permission denied while trying to connect to the Docker daemon socket")
+	var missingErr *errdefs.DependencyMissingError
+	require.NotErrorAs(t, err, &missingErr)
+
+	err = kurtosis.StartEngine(context.Background())
+	require.ErrorContains(t, err, "`docker ps --all --quiet")
+	require.ErrorContains(t, err, "permission denied while trying to connect to the Docker daemon socket")
+	require.NotErrorAs(t, err, &missingErr)
+}
+
+// Starts the engine with a short timeout, since there's no engine API to wait for in tests.
+func startEngineWithTimeout(t *testing.T, start func(context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := start(ctx)
+	if err != nil {
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+}
+
+func TestStartEngineReusesStoppedContainer(t *testing.T) {
+	logPath := fakeCommand(t, "docker", "abc123", "", 0)
+
+	startEngineWithTimeout(t, kurtosis.StartEngine)
+	require.Equal(t, []string{
+		"ps --all --quiet --filter label=com.kurtosistech.app-id=kurtosis" +
+			" --filter label=com.kurtosistech.container-type=kurtosis-engine" +
+			" --filter ancestor=kurtosistech/engine:" + kurtosis.SdkVersion,
+		"start abc123",
+	}, loggedCalls(t, logPath))
+}
+
+func TestStartEngineCreatesContainer(t *testing.T) {
+	logPath := fakeCommand(t, "docker", "", "", 0)
+
+	startEngineWithTimeout(t, kurtosis.StartEngine)
+	calls := loggedCalls(t, logPath)
+	require.Len(t, calls, 2)
+	createCall := calls[1]
+	require.True(t, strings.HasPrefix(createCall, "run --detach --name kurtosis-engine--"), createCall)
+	require.Contains(t, createCall, "--label com.kurtosistech.app-id=kurtosis")
+	require.Contains(t, createCall, "--label com.kurtosistech.container-type=kurtosis-engine")
+	require.Contains(t, createCall, "--publish 9710:9710")
+	require.Contains(t, createCall, "--volume /var/run/docker.sock:/var/run/docker.sock")
+	require.Contains(t, createCall, `"imageVersionTag":"`+kurtosis.SdkVersion+`"`)
+	require.True(t, strings.HasSuffix(createCall, " kurtosistech/engine:"+kurtosis.SdkVersion), createCall)
+}
+
+func TestRestartEngine(t *testing.T) {
+	logPath := fakeCommand(t, "docker", "", "", 0)
+
+	// The engine isn't running, so it's only started
+	startEngineWithTimeout(t, kurtosis.RestartEngine)
+	calls := loggedCalls(t, logPath)
+	require.Len(t, calls, 3)
+	require.True(t, strings.HasPrefix(calls[0], "ps --quiet "), calls[0])
+	require.True(t, strings.HasPrefix(calls[1], "ps --all --quiet "), calls[1])
+	require.True(t, strings.HasPrefix(calls[2], "run --detach "), calls[2])
+}
+
+func TestStartEngineWithoutDocker(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	err := kurtosis.StartEngine(context.Background())
+	var missingErr *errdefs.DependencyMissingError
+	require.ErrorAs(t, err, &missingErr)
+	require.Equal(t, "docker", missingErr.Dependency)
+}
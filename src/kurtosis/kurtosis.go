@@ -2,10 +2,10 @@ package kurtosis
 
 import (
 	"context"
-	"fmt"
-	"os/exec"
+	"errors"
 
 	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/enclaves"
+	"github.com/kurtosis-tech/kurtosis/api/golang/core/lib/services"
 	"github.com/kurtosis-tech/kurtosis/api/golang/engine/lib/kurtosis_context"
 )
 
@@ -17,18 +17,26 @@ type EnclaveCtx struct {
 	*enclaves.EnclaveContext
 }
 
+// Connects to the local Kurtosis engine, starting it if it's not running.
+// Fails with an errdefs.EngineVersionMismatchError if the engine's version is incompatible with the SDK's.
 func InitKurtosisContext() (KurtosisCtx, error) {
-	ctx, err := kurtosis_context.NewKurtosisContextFromLocalEngine()
-	if err != nil {
-		// Kurtosis engine is probably not running. Try to start it.
-		// TODO: avoid using the CLI for this
-		if exec.Command("kurtosis", "engine", "start").Run() != nil {
-			return KurtosisCtx{}, fmt.Errorf("failed to start Kurtosis engine: %w\n"+
-				"This might be because the docker daemon is not running", err)
+	ctx := context.Background()
+	engineVersion, err := GetEngineVersion(ctx)
+	if errors.Is(err, ErrEngineNotRunning) {
+		err = StartEngine(ctx)
+		if err != nil {
+			return KurtosisCtx{}, err
 		}
-		ctx, err = kurtosis_context.NewKurtosisContextFromLocalEngine()
+		engineVersion, err = GetEngineVersion(ctx)
+	}
+	if err != nil {
+		return KurtosisCtx{}, err
+	}
+	if err = CheckEngineVersion(engineVersion); err != nil {
+		return KurtosisCtx{}, err
 	}
-	return KurtosisCtx{ctx}, err
+	kurtosisCtx, err := kurtosis_context.NewKurtosisContextFromLocalEngine()
+	return KurtosisCtx{kurtosisCtx}, err
 }
 
 func (kCtx KurtosisCtx) EnclaveExists(ctx context.Context, devnetName string) bool {
@@ -40,3 +48,44 @@ func (kCtx KurtosisCtx) GetEnclaveCtx(ctx context.Context, devnetName string) (E
 	enclaveContext, err := kCtx.KurtosisContext.GetEnclaveContext(ctx, devnetName)
 	return EnclaveCtx{enclaveContext}, err
 }
+
+// Returns the last `numLines` log lines of each service in the enclave, keyed by service name.
+func (kCtx KurtosisCtx) CollectServiceLogs(
+	ctx context.Context,
+	devnetName string,
+	numLines uint32,
+) (map[string][]string, error) {
+	enclaveCtx, err := kCtx.GetEnclaveCtx(ctx, devnetName)
+	if err != nil {
+		return nil, err
+	}
+	serviceUuids, err := enclaveCtx.GetServices()
+	if err != nil {
+		return nil, err
+	}
+	uuidToName := make(map[services.ServiceUUID]string, len(serviceUuids))
+	requestedUuids := make(map[services.ServiceUUID]bool, len(serviceUuids))
+	for name, uuid := range serviceUuids {
+		uuidToName[uuid] = string(name)
+		requestedUuids[uuid] = true
+	}
+	logs := make(map[string][]string, len(serviceUuids))
+	if len(serviceUuids) == 0 {
+		return logs, nil
+	}
+	logsChan, cancel, err := kCtx.GetServiceLogs(ctx, devnetName, requestedUuids, false, false, numLines, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	// The channel is closed once all logs were received
+	for content := range logsChan {
+		for uuid, serviceLogs := range content.GetServiceLogsByServiceUuids() {
+			name := uuidToName[uuid]
+			for _, logLine := range serviceLogs {
+				logs[name] = append(logs[name], logLine.GetContent())
+			}
+		}
+	}
+	return logs, nil
+}
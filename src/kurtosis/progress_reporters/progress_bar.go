@@ -15,10 +15,44 @@ var _ Reporter = (*ProgressBarReporter)(nil)
 // A reporter that reports progress via a progress bar.
 type ProgressBarReporter struct {
 	pb *progressbar.ProgressBar
+	// Print the full output of each step
+	verbose bool
 }
 
-func NewProgressBarReporter() *ProgressBarReporter {
-	return &ProgressBarReporter{}
+func NewProgressBarReporter(verbose bool) *ProgressBarReporter {
+	return &ProgressBarReporter{verbose: verbose}
+}
+
+func (r *ProgressBarReporter) ReportPreparationStart(totalSteps int) error {
+	r.changeProgressBar(totalSteps, "Preparing devnet...")
+	return nil
+}
+
+func (r *ProgressBarReporter) ReportBuildStep(stepInfo PreparationStep) error {
+	r.reportPreparationStep(stepInfo)
+	return nil
+}
+
+func (r *ProgressBarReporter) ReportBuildOutput(imageName string, line string) error {
+	if !r.verbose {
+		// Only show the latest line
+		addDetail(r.pb, line)
+		return nil
+	}
+	_ = r.pb.Clear()
+	fmt.Printf("[%s] %s\n", imageName, line)
+	_ = r.pb.RenderBlank()
+	return nil
+}
+
+func (r *ProgressBarReporter) ReportUploadStep(stepInfo PreparationStep) error {
+	r.reportPreparationStep(stepInfo)
+	return nil
+}
+
+func (r *ProgressBarReporter) ReportDownloadStep(stepInfo PreparationStep) error {
+	r.reportPreparationStep(stepInfo)
+	return nil
 }
 
 func (r *ProgressBarReporter) ReportInterpretationStart() error {
@@ -40,6 +74,11 @@ func (r *ProgressBarReporter) ReportValidationStep(stepInfo ValidationStep) erro
 }
 
 func (r *ProgressBarReporter) ReportExecutionStart(totalSteps int) error {
+	// Backends that execute instructions as they're interpreted don't know the step count.
+	// Show a spinner in that case.
+	if totalSteps == 0 {
+		totalSteps = -1
+	}
 	r.changeProgressBar(totalSteps, "Starting execution...")
 	return nil
 }
@@ -50,10 +89,11 @@ func (r *ProgressBarReporter) ReportExecutionStep(stepInfo ExecutionStep) error
 	if stepInfo.InstructionDescription != nil {
 		addDetail(r.pb, *stepInfo.InstructionDescription)
 	}
-	// TODO: implement verbosity levels and print this only on verbose
-	// if stepInfo.InstructionResult != nil {
-	// 	fmt.Println(*stepInfo.InstructionResult)
-	// }
+	if r.verbose && stepInfo.InstructionResult != nil {
+		_ = r.pb.Clear()
+		fmt.Println(*stepInfo.InstructionResult)
+		_ = r.pb.RenderBlank()
+	}
 	return nil
 }
 
@@ -72,7 +112,7 @@ func (r *ProgressBarReporter) ReportWarning(message string) error {
 }
 
 func (r *ProgressBarReporter) ReportRunFinished(success bool, output string) error {
-	_ = r.pb.Finish()
+	finishBar(r.pb)
 	_ = r.pb.Clear()
 	if success {
 		fmt.Printf("Devnet started in %.1fs\n", r.pb.State().SecondsSince)
@@ -82,6 +122,15 @@ func (r *ProgressBarReporter) ReportRunFinished(success bool, output string) err
 	return nil
 }
 
+func (r *ProgressBarReporter) reportPreparationStep(stepInfo PreparationStep) {
+	if stepInfo.CurrentStep >= stepInfo.TotalSteps {
+		finishBar(r.pb)
+	} else {
+		_ = r.pb.Set(stepInfo.CurrentStep)
+	}
+	r.pb.Describe(stepInfo.Description)
+}
+
 func (r *ProgressBarReporter) changeProgressBar(steps int, message string) {
 	if r.pb != nil {
 		clearBar(r.pb)
@@ -149,11 +198,17 @@ func addDetail(pb *progressbar.ProgressBar, detail string) {
 	_ = pb.AddDetail(detail)
 }
 
+// Completes all the steps of the progress bar.
+func finishBar(pb *progressbar.ProgressBar) {
+	// Finishing a bar without any details panics, which happens when no step added one
+	_ = pb.AddDetail("")
+	_ = pb.Finish()
+}
+
 // Ends and clears the progress bar.
 func clearBar(pb *progressbar.ProgressBar) {
 	// Ignore any errors
 	_ = pb.Set(1)
-	_ = pb.AddDetail("")
-	_ = pb.Finish()
+	finishBar(pb)
 	_ = pb.Clear()
 }
@@ -2,13 +2,24 @@ package progress_reporters
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
 	kapi "github.com/kurtosis-tech/kurtosis/api/golang/core/kurtosis_core_rpc_api_bindings"
 )
 
 type KurtosisResponse = *kapi.StarlarkRunResponseLine
 
+var (
+	// Returned when a response line has an unknown or unexpected type.
+	ErrUnexpectedResponse = errors.New("received unexpected Kurtosis response")
+	// Returned when an instruction is received outside of the execution phase.
+	ErrInstructionOutsideExecution = fmt.Errorf("%w: instruction outside of execution state", ErrUnexpectedResponse)
+	// Returned when a progress info line doesn't contain any step info.
+	ErrEmptyStepInfo = fmt.Errorf("%w: progress info without step info", ErrUnexpectedResponse)
+)
+
 type State int
 
 const (
@@ -32,7 +43,31 @@ type ExecutionStep struct {
 	InstructionResult      *string
 }
 
+// A single step of the preparation phase (builds, uploads, downloads),
+// which runs before the Kurtosis package. Steps are counted across all kinds.
+type PreparationStep struct {
+	CurrentStep int
+	TotalSteps  int
+	Description string
+}
+
 type Reporter interface {
+	// Signals the start of the preparation phase, where images are built and files uploaded.
+	// Its steps are reported via ReportBuildStep, ReportUploadStep and ReportDownloadStep.
+	ReportPreparationStart(totalSteps int) error
+
+	// Signals an image build finished
+	ReportBuildStep(stepInfo PreparationStep) error
+
+	// Signals a line of output of an image build
+	ReportBuildOutput(imageName string, line string) error
+
+	// Signals files were uploaded to the enclave, like a local repo or an artifact with static files
+	ReportUploadStep(stepInfo PreparationStep) error
+
+	// Signals a single static file of an artifact was fetched, either downloaded or copied from disk
+	ReportDownloadStep(stepInfo PreparationStep) error
+
 	// Signals the start of the interpretation phase
 	ReportInterpretationStart() error
 
@@ -60,25 +95,45 @@ type Reporter interface {
 	ReportRunFinished(success bool, output string) error
 }
 
+// Options accepted by ReportProgressWithOptions.
+type ReportOptions struct {
+	// When set, unexpected response lines are skipped instead of aborting with an ErrUnexpectedResponse error.
+	// Only the first one is reported as a warning.
+	// Useful for staying compatible with newer Kurtosis versions.
+	SkipUnexpectedResponses bool
+}
+
 // This function reads the Kurtosis response channel and reports the progress to the reporter.
+// Fails with ErrUnexpectedResponse if an unexpected response line is received.
 func ReportProgress(reporter Reporter, responseChan chan KurtosisResponse) error {
+	return ReportProgressWithOptions(reporter, responseChan, ReportOptions{})
+}
+
+// Same as ReportProgress, but with the behavior customized via ReportOptions.
+func ReportProgressWithOptions(reporter Reporter, responseChan chan KurtosisResponse, opts ReportOptions) error {
 	state := Interpretation
 	var totalSteps uint32
 	var currentExecutionStep ExecutionStep
+	skippedResponses := false
 	for line := range responseChan {
 		var err error
 		switch {
 		case line.GetProgressInfo() != nil:
 			// It's a progress info
 			progressInfo := line.GetProgressInfo()
+			if len(progressInfo.GetCurrentStepInfo()) == 0 {
+				err = ErrEmptyStepInfo
+				break
+			}
 			description := progressInfo.GetCurrentStepInfo()[0]
 
 			err = reportProgressInfo(&state, &totalSteps, &currentExecutionStep, reporter, progressInfo, description)
 		case line.GetInstruction() != nil:
 			// It's an instruction
 			instruction := line.GetInstruction()
-			if state != Execution { // This should never happen
-				panic("Received instruction outside of execution state")
+			if state != Execution {
+				err = ErrInstructionOutsideExecution
+				break
 			}
 			currentExecutionStep.InstructionDescription = &instruction.Description
 			err = reporter.ReportExecutionStep(currentExecutionStep)
@@ -99,7 +154,15 @@ func ReportProgress(reporter Reporter, responseChan chan KurtosisResponse) error
 			event := line.GetRunFinishedEvent()
 			return reporter.ReportRunFinished(event.GetIsRunSuccessful(), event.GetSerializedOutput())
 		default:
-			panic("Received unknown Kurtosis response type")
+			err = fmt.Errorf("%w: unknown response type %T", ErrUnexpectedResponse, line.GetRunResponseLine())
+		}
+		if opts.SkipUnexpectedResponses && errors.Is(err, ErrUnexpectedResponse) {
+			message := "Skipping unexpected Kurtosis responses, starting with: " + err.Error()
+			err = nil
+			if !skippedResponses {
+				skippedResponses = true
+				err = reporter.ReportWarning(message)
+			}
 		}
 		if err != nil {
 			return err
@@ -186,16 +249,16 @@ func reportProgressInfo(
 	return nil
 }
 
+// Converts a Starlark error to one of the error types in errdefs.
 func getKurtosisError(starlarkError *kapi.StarlarkError) error {
-	var msg string
 	if err := starlarkError.GetValidationError(); err != nil {
-		msg = err.GetErrorMessage()
+		return &errdefs.ValidationError{Message: err.GetErrorMessage()}
 	}
 	if err := starlarkError.GetInterpretationError(); err != nil {
-		msg = err.GetErrorMessage()
+		return &errdefs.InterpretationError{Message: err.GetErrorMessage()}
 	}
 	if err := starlarkError.GetExecutionError(); err != nil {
-		msg = err.GetErrorMessage()
+		return &errdefs.ExecutionError{Message: err.GetErrorMessage()}
 	}
-	return errors.New("error occurred during execution: " + msg)
+	return fmt.Errorf("unknown error occurred during execution: %v", starlarkError)
 }
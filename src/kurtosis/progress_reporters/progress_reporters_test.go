@@ -0,0 +1,221 @@
+package progress_reporters_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Layr-Labs/avs-devnet/src/errdefs"
+	"github.com/Layr-Labs/avs-devnet/src/kurtosis/progress_reporters"
+	kapi "github.com/kurtosis-tech/kurtosis/api/golang/core/kurtosis_core_rpc_api_bindings"
+	"github.com/stretchr/testify/require"
+)
+
+// A reporter that records every call it receives.
+type recordingReporter struct {
+	calls []string
+}
+
+func (r *recordingReporter) ReportPreparationStart(totalSteps int) error {
+	r.calls = append(r.calls, fmt.Sprintf("preparation-start %d", totalSteps))
+	return nil
+}
+
+func (r *recordingReporter) ReportBuildStep(stepInfo progress_reporters.PreparationStep) error {
+	r.calls = append(r.calls, fmt.Sprintf("build-step %d", stepInfo.CurrentStep))
+	return nil
+}
+
+func (r *recordingReporter) ReportBuildOutput(imageName string, line string) error {
+	r.calls = append(r.calls, fmt.Sprintf("build-output %s %s", imageName, line))
+	return nil
+}
+
+func (r *recordingReporter) ReportUploadStep(stepInfo progress_reporters.PreparationStep) error {
+	r.calls = append(r.calls, fmt.Sprintf("upload-step %d", stepInfo.CurrentStep))
+	return nil
+}
+
+func (r *recordingReporter) ReportDownloadStep(stepInfo progress_reporters.PreparationStep) error {
+	r.calls = append(r.calls, fmt.Sprintf("download-step %d", stepInfo.CurrentStep))
+	return nil
+}
+
+func (r *recordingReporter) ReportInterpretationStart() error {
+	r.calls = append(r.calls, "interpretation")
+	return nil
+}
+
+func (r *recordingReporter) ReportValidationStart(totalSteps int) error {
+	r.calls = append(r.calls, fmt.Sprintf("validation-start %d", totalSteps))
+	return nil
+}
+
+func (r *recordingReporter) ReportValidationStep(stepInfo progress_reporters.ValidationStep) error {
+	r.calls = append(r.calls, fmt.Sprintf("validation-step %d", stepInfo.CurrentStep))
+	return nil
+}
+
+func (r *recordingReporter) ReportExecutionStart(totalSteps int) error {
+	r.calls = append(r.calls, fmt.Sprintf("execution-start %d", totalSteps))
+	return nil
+}
+
+func (r *recordingReporter) ReportExecutionStep(stepInfo progress_reporters.ExecutionStep) error {
+	r.calls = append(r.calls, fmt.Sprintf("execution-step %d", stepInfo.CurrentStep))
+	return nil
+}
+
+func (r *recordingReporter) ReportInfo(message string) error {
+	r.calls = append(r.calls, "info "+message)
+	return nil
+}
+
+func (r *recordingReporter) ReportWarning(message string) error {
+	r.calls = append(r.calls, "warning "+message)
+	return nil
+}
+
+func (r *recordingReporter) ReportRunFinished(success bool, _ string) error {
+	r.calls = append(r.calls, fmt.Sprintf("finished %t", success))
+	return nil
+}
+
+func progressLine(step, total uint32, info ...string) progress_reporters.KurtosisResponse {
+	return &kapi.StarlarkRunResponseLine{
+		RunResponseLine: &kapi.StarlarkRunResponseLine_ProgressInfo{
+			ProgressInfo: &kapi.StarlarkRunProgress{
+				CurrentStepInfo:   info,
+				TotalSteps:        total,
+				CurrentStepNumber: step,
+			},
+		},
+	}
+}
+
+func instructionLine(description string) progress_reporters.KurtosisResponse {
+	return &kapi.StarlarkRunResponseLine{
+		RunResponseLine: &kapi.StarlarkRunResponseLine_Instruction{
+			Instruction: &kapi.StarlarkInstruction{Description: description},
+		},
+	}
+}
+
+func finishedLine(success bool) progress_reporters.KurtosisResponse {
+	return &kapi.StarlarkRunResponseLine{
+		RunResponseLine: &kapi.StarlarkRunResponseLine_RunFinishedEvent{
+			RunFinishedEvent: &kapi.StarlarkRunFinishedEvent{IsRunSuccessful: success},
+		},
+	}
+}
+
+func emptyLine() progress_reporters.KurtosisResponse {
+	return &kapi.StarlarkRunResponseLine{}
+}
+
+func toChan(lines ...progress_reporters.KurtosisResponse) chan progress_reporters.KurtosisResponse {
+	responseChan := make(chan progress_reporters.KurtosisResponse, len(lines))
+	for _, line := range lines {
+		responseChan <- line
+	}
+	close(responseChan)
+	return responseChan
+}
+
+func TestReportProgressFullRun(t *testing.T) {
+	t.Parallel()
+	reporter := &recordingReporter{}
+	responseChan := toChan(
+		progressLine(0, 0, "Interpreting plan - execution will begin shortly"),
+		progressLine(0, 2, "Starting validation"),
+		progressLine(1, 2, "Validating plan", "detail"),
+		progressLine(0, 2, "Starting execution"),
+		progressLine(1, 2, "Executing step 1"),
+		instructionLine("print()"),
+		finishedLine(true),
+	)
+	err := progress_reporters.ReportProgress(reporter, responseChan)
+	require.NoError(t, err)
+	expected := []string{
+		"interpretation",
+		"validation-start 2",
+		"validation-step 1",
+		"execution-start 2",
+		"execution-step 0",
+		"execution-step 0",
+		"finished true",
+	}
+	require.Equal(t, expected, reporter.calls)
+}
+
+func TestReportProgressUnexpectedResponses(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]progress_reporters.KurtosisResponse{
+		"unknown type":          emptyLine(),
+		"empty step info":       progressLine(0, 0),
+		"instruction too early": instructionLine("print()"),
+	}
+	for name, line := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			reporter := &recordingReporter{}
+			err := progress_reporters.ReportProgress(reporter, toChan(line, finishedLine(true)))
+			require.ErrorIs(t, err, progress_reporters.ErrUnexpectedResponse)
+			require.Empty(t, reporter.calls)
+		})
+	}
+}
+
+func TestReportProgressSkipsUnexpectedResponses(t *testing.T) {
+	t.Parallel()
+	reporter := &recordingReporter{}
+	responseChan := toChan(
+		emptyLine(),
+		progressLine(0, 0),
+		instructionLine("print()"),
+		finishedLine(true),
+	)
+	opts := progress_reporters.ReportOptions{SkipUnexpectedResponses: true}
+	err := progress_reporters.ReportProgressWithOptions(reporter, responseChan, opts)
+	require.NoError(t, err)
+	// Only the first skipped line is reported
+	require.Len(t, reporter.calls, 2)
+	require.Contains(t, reporter.calls[0], "warning ")
+	require.Equal(t, "finished true", reporter.calls[1])
+}
+
+func TestReportProgressReturnsTypedErrors(t *testing.T) {
+	t.Parallel()
+	errorLine := func(starlarkError *kapi.StarlarkError) progress_reporters.KurtosisResponse {
+		return &kapi.StarlarkRunResponseLine{
+			RunResponseLine: &kapi.StarlarkRunResponseLine_Error{Error: starlarkError},
+		}
+	}
+	validationLine := errorLine(&kapi.StarlarkError{
+		Error: &kapi.StarlarkError_ValidationError{
+			ValidationError: &kapi.StarlarkValidationError{ErrorMessage: "bad"},
+		},
+	})
+	interpretationLine := errorLine(&kapi.StarlarkError{
+		Error: &kapi.StarlarkError_InterpretationError{
+			InterpretationError: &kapi.StarlarkInterpretationError{ErrorMessage: "bad"},
+		},
+	})
+	executionLine := errorLine(&kapi.StarlarkError{
+		Error: &kapi.StarlarkError_ExecutionError{
+			ExecutionError: &kapi.StarlarkExecutionError{ErrorMessage: "bad"},
+		},
+	})
+
+	err := progress_reporters.ReportProgress(&recordingReporter{}, toChan(validationLine))
+	var validationErr *errdefs.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	err = progress_reporters.ReportProgress(&recordingReporter{}, toChan(interpretationLine))
+	var interpretationErr *errdefs.InterpretationError
+	require.ErrorAs(t, err, &interpretationErr)
+
+	err = progress_reporters.ReportProgress(&recordingReporter{}, toChan(executionLine))
+	var executionErr *errdefs.ExecutionError
+	require.ErrorAs(t, err, &executionErr)
+	require.Equal(t, "bad", executionErr.Message)
+}